@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+type taskBudget struct {
+	Budget    time.Duration
+	Spent     time.Duration
+	Remaining time.Duration
+	Ratio     float64
+	Loaded    bool
+	Err       error // Time spent could not be fetched
+}
+
+func isHoursBudget(task *TaskResponse) bool {
+	return task.Budgeted > 0 && (task.BudgetUnit == "" || task.BudgetUnit == "hours")
+}
+
+func (app *App) taskBudget(task *TaskResponse) taskBudget {
+	budget := taskBudget{
+		Budget: time.Duration(task.Budgeted) * time.Hour,
+	}
+	app.taskSpentMutex.Lock()
+	spent, ok := app.taskSpent[task.TaskID]
+	loading := app.taskSpentLoading[task.TaskID]
+	budget.Err = app.taskSpentErrors[task.TaskID]
+	if !ok && !loading && budget.Err == nil {
+		if app.taskSpentLoading == nil {
+			app.taskSpentLoading = make(map[int]bool)
+		}
+		app.taskSpentLoading[task.TaskID] = true
+		app.taskSpentQueue = append(app.taskSpentQueue, task.TaskID)
+	}
+	app.taskSpentMutex.Unlock()
+	if !ok {
+		return budget
+	}
+	budget.Loaded = true
+	budget.Spent = spent
+	budget.Remaining = budget.Budget - spent
+	if budget.Budget > 0 {
+		budget.Ratio = float64(spent) / float64(budget.Budget)
+	}
+	return budget
+}
+
+// fetchQueuedTaskSpent sends a single request for every budget asked for
+// since the last call, so drawing the picker does not fetch once per task
+func (app *App) fetchQueuedTaskSpent() {
+	app.taskSpentMutex.Lock()
+	queue := app.taskSpentQueue
+	app.taskSpentQueue = nil
+	app.taskSpentMutex.Unlock()
+	if len(queue) > 0 {
+		go app.fetchTaskSpent(queue)
+	}
+}
+
+// fetchTaskSpent sums the time the whole team logged on each budgeted task and
+// all its descendants, archived ones included. Entries are only requested from
+// the day the oldest of these tasks was created
+func (app *App) fetchTaskSpent(budgetIDs []int) error {
+	owners := make(map[string][]int) // Budgeted tasks every task counts towards
+	from := time.Now()
+	for _, budgetID := range budgetIDs {
+		owners[strconv.Itoa(budgetID)] = nil
+		added := time.Date(2000, 1, 1, 0, 0, 0, 0, time.Local) // Creation date unknown
+		if task := findTask(app.tasks, budgetID); task != nil && len(task.AddDate) >= 10 {
+			if date, err := time.ParseInLocation("2006-01-02", task.AddDate[:10], time.Local); err == nil {
+				added = date
+			}
+		}
+		if added.Before(from) {
+			from = added
+		}
+	}
+	for _, task := range app.tasks {
+		taskID := strconv.Itoa(task.TaskID)
+		for _, budgetID := range budgetIDs {
+			if app.isInTaskSubtree(taskID, budgetID) {
+				owners[taskID] = append(owners[taskID], budgetID)
+			}
+		}
+	}
+	for _, budgetID := range budgetIDs {
+		taskID := strconv.Itoa(budgetID)
+		if len(owners[taskID]) == 0 {
+			owners[taskID] = []int{budgetID} // Not in the task list
+		}
+	}
+	taskIDs := make([]string, 0, len(owners))
+	for taskID := range owners {
+		taskIDs = append(taskIDs, taskID)
+	}
+	sort.Strings(taskIDs)
+	var entries []EntryResponse
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint: fmt.Sprintf("/entries?from=%s&to=%s&task_ids=%s&user_ids=all", from.Format("2006-01-02"), time.Now().Format("2006-01-02"), strings.Join(taskIDs, ",")),
+		Method:   "GET",
+		Response: &entries,
+		Headers:  map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	spent := make(map[int]time.Duration)
+	for _, entry := range entries {
+		seconds, _ := strconv.ParseInt(entry.Duration, 10, 64)
+		for _, budgetID := range owners[entry.TaskID] {
+			spent[budgetID] += time.Duration(seconds) * time.Second
+		}
+	}
+
+	app.taskSpentMutex.Lock()
+	defer app.taskSpentMutex.Unlock()
+	for _, budgetID := range budgetIDs {
+		delete(app.taskSpentLoading, budgetID)
+	}
+	if result.Error != nil {
+		// Remembered until the next invalidation so redraws do not retry
+		if app.taskSpentErrors == nil {
+			app.taskSpentErrors = make(map[int]error)
+		}
+		for _, budgetID := range budgetIDs {
+			app.taskSpentErrors[budgetID] = result.Error
+		}
+		app.vx.PostEvent(vaxis.Redraw{})
+		return fmt.Errorf("failed API response: %w", result.Error)
+	}
+	if app.taskSpent == nil {
+		app.taskSpent = make(map[int]time.Duration)
+	}
+	for _, budgetID := range budgetIDs {
+		app.taskSpent[budgetID] = spent[budgetID]
+	}
+	app.vx.PostEvent(vaxis.Redraw{})
+	return nil
+}
+
+func (app *App) invalidateTaskSpent() {
+	app.taskSpentMutex.Lock()
+	app.taskSpent = nil
+	app.taskSpentErrors = nil
+	app.taskSpentMutex.Unlock()
+}
+
+func (app *App) budgetStyle(ratio float64) vaxis.Style {
+	switch {
+	case ratio >= 1:
+		return app.style("error") // Overrun
+	case ratio >= 0.8:
+		return app.style("warning") // Close to the budget
+	}
+	return app.style("success")
+}
+
+func (app *App) taskBudgetSegments(task *TaskResponse) []vaxis.Segment {
+	if task.Budgeted <= 0 {
+		return nil
+	}
+	if !isHoursBudget(task) {
+		return []vaxis.Segment{{
+			Text:  fmt.Sprintf(" %d %s", task.Budgeted, task.BudgetUnit),
+			Style: app.style("muted"),
+		}}
+	}
+	budget := app.taskBudget(task)
+	if budget.Err != nil {
+		return []vaxis.Segment{{
+			Text:  fmt.Sprintf(" ?/%s", formatHours(budget.Budget)),
+			Style: app.style("error"),
+		}}
+	}
+	if !budget.Loaded {
+		return []vaxis.Segment{{
+			Text:  fmt.Sprintf(" …/%s", formatHours(budget.Budget)),
+			Style: app.style("muted"),
+		}}
+	}
+	return []vaxis.Segment{
+		{
+			Text:  fmt.Sprintf(" %s/%s ", formatHours(budget.Spent), formatHours(budget.Budget)),
+			Style: app.style("muted"),
+		},
+		{
+			Text:  progressBar(budget.Ratio, 8),
+			Style: app.budgetStyle(budget.Ratio),
+		},
+	}
+}
+
+func (app *App) drawTaskDetail(win vaxis.Window) {
+	task := findTask(app.tasks, app.highlightedTaskID())
+	if task == nil {
+		win.Println(0, vaxis.Segment{
+			Text:  "No task selected",
+			Style: app.style("loading"),
+		})
+		return
+	}
+	label := vaxis.Style{Attribute: vaxis.AttrBold}
+	win.Println(0, app.taskRowSegments(task, "", label)...)
+
+	row := 2
+	if parent := findTask(app.tasks, task.ParentID); parent != nil {
+		win.Println(row, vaxis.Segment{Text: "Parent:    ", Style: label}, vaxis.Segment{Text: parent.Name})
+		row++
+	}
+	if task.Keywords != "" {
+		win.Println(row, vaxis.Segment{Text: "Keywords:  ", Style: label}, vaxis.Segment{Text: task.Keywords})
+		row++
+	}
+	if task.ExternalTaskID != "" {
+		win.Println(row, vaxis.Segment{Text: "External:  ", Style: label}, vaxis.Segment{Text: task.ExternalTaskID})
+		row++
+	}
+	row++
+
+	if task.Budgeted <= 0 {
+		win.Println(row, vaxis.Segment{
+			Text:  "No budget set",
+			Style: app.style("loading"),
+		})
+		return
+	}
+	if !isHoursBudget(task) {
+		win.Println(row, vaxis.Segment{Text: "Budget:    ", Style: label}, vaxis.Segment{
+			Text: fmt.Sprintf("%d %s", task.Budgeted, task.BudgetUnit),
+		})
+		return
+	}
+	budget := app.taskBudget(task)
+	win.Println(row, vaxis.Segment{Text: "Budget:    ", Style: label}, vaxis.Segment{Text: formatHours(budget.Budget)})
+	if budget.Err != nil {
+		win.Println(row+1, vaxis.Segment{
+			Text:  "Time spent unavailable: " + budget.Err.Error(),
+			Style: app.style("error"),
+		})
+		return
+	}
+	if !budget.Loaded {
+		win.Println(row+1, vaxis.Segment{
+			Text:  "Loading time spent...",
+			Style: app.style("loading"),
+		})
+		return
+	}
+	win.Println(row+1, vaxis.Segment{Text: "Spent:     ", Style: label}, vaxis.Segment{Text: formatHours(budget.Spent)})
+	remaining := formatHours(budget.Remaining)
+	if budget.Remaining < 0 {
+		remaining = "over by " + formatHours(-budget.Remaining)
+	}
+	win.Println(row+2, vaxis.Segment{Text: "Remaining: ", Style: label}, vaxis.Segment{
+		Text:  remaining,
+		Style: app.budgetStyle(budget.Ratio),
+	})
+	width, _ := win.Size()
+	win.Println(row+4, vaxis.Segment{
+		Text:  progressBar(budget.Ratio, min(40, width-6)),
+		Style: app.budgetStyle(budget.Ratio),
+	}, vaxis.Segment{
+		Text: fmt.Sprintf(" %d%%", int(budget.Ratio*100)),
+	})
+}
+
+func formatHours(d time.Duration) string {
+	hours := int(d.Hours())
+	minutes := int(d.Minutes()) % 60
+	if minutes == 0 {
+		return fmt.Sprintf("%dh", hours)
+	}
+	return fmt.Sprintf("%dh%02dm", hours, minutes)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+const (
+	BulkInputNone = iota
+	BulkInputShift
+	BulkInputMove
+	BulkInputSplit
+	BulkInputTagFilter
+	BulkInputTextFilter
+)
+
+type batchProgress struct {
+	label string
+	done  int
+	total int
+}
+
+// markedIndexes returns the indexes into app.entries of the visible rows that
+// are marked or inside the visual range, which follows the rows on screen
+func (app *App) markedIndexes(visible []int) map[int]bool {
+	marked := make(map[int]bool)
+	anchor, selected := -1, -1
+	for i, index := range visible {
+		if app.markedEntries[app.entries[index].ID] {
+			marked[index] = true
+		}
+		if index == app.visualAnchor {
+			anchor = i
+		}
+		if index == app.selectedEntry {
+			selected = i
+		}
+	}
+	if app.visualAnchor >= 0 && anchor >= 0 && selected >= 0 {
+		for _, index := range visible[min(anchor, selected) : max(anchor, selected)+1] {
+			marked[index] = true
+		}
+	}
+	return marked
+}
+
+func (app *App) hasMarkedEntries() bool {
+	return len(app.markedEntries) > 0 || app.visualAnchor >= 0
+}
+
+// targetEntries returns the marked entries, or the selected entry when nothing is marked
+func (app *App) targetEntries() []EntryResponse {
+	var targets []EntryResponse
+	visible := app.visibleEntryIndexes()
+	marked := app.markedIndexes(visible)
+	for _, index := range visible {
+		if marked[index] {
+			targets = append(targets, app.entries[index])
+		}
+	}
+	if len(targets) == 0 && app.hasVisibleSelection() {
+		targets = append(targets, app.entries[app.selectedEntry])
+	}
+	return targets
+}
+
+func (app *App) clearMarks() {
+	app.markedEntries = map[int64]bool{}
+	app.visualAnchor = -1
+}
+
+func (app *App) toggleMark() {
+	if !app.hasVisibleSelection() {
+		return
+	}
+	if app.markedEntries == nil {
+		app.markedEntries = map[int64]bool{}
+	}
+	id := app.entries[app.selectedEntry].ID
+	if app.markedEntries[id] {
+		delete(app.markedEntries, id)
+	} else {
+		app.markedEntries[id] = true
+	}
+}
+
+func (app *App) toggleVisual() {
+	if app.visualAnchor < 0 {
+		app.visualAnchor = app.selectedEntry
+		return
+	}
+	if app.markedEntries == nil {
+		app.markedEntries = map[int64]bool{}
+	}
+	for index := range app.markedIndexes(app.visibleEntryIndexes()) {
+		app.markedEntries[app.entries[index].ID] = true
+	}
+	app.visualAnchor = -1
+}
+
+// runBatch applies fn to every entry in the background, reporting progress and
+// a single summary of failures once all of them have been processed
+func (app *App) runBatch(label string, entries []EntryResponse, fn func(EntryResponse) error) {
+	if len(entries) == 0 || app.batch != nil {
+		return
+	}
+	app.batch = &batchProgress{label: label, total: len(entries)}
+	app.clearMarks()
+	go func() {
+		var failures []string
+		app.beginJournalGroup()
+		for _, entry := range entries {
+			err := lockedError(entry)
+			if !isEntryLocked(entry) {
+				err = fn(entry)
+			}
+			if err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", entry.StartTime, err))
+			}
+			app.batch.done++
+			app.vx.PostEvent(vaxis.Redraw{})
+		}
+		app.endJournalGroup()
+		app.batch = nil
+		app.fetchEntries(app.selectedDate)
+		if len(failures) > 0 {
+			app.statusMessage = fmt.Sprintf("%s: %d of %d failed: %s", label, len(failures), len(entries), strings.Join(failures, ", "))
+		} else {
+			app.statusMessage = fmt.Sprintf("%s: %d entries done", label, len(entries))
+		}
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
+
+func (app *App) bulkDelete(entries []EntryResponse) {
+	app.runBatch("Delete", entries, func(entry EntryResponse) error {
+		return app.deleteEntry(entry.ID)
+	})
+}
+
+func (app *App) bulkSetTask(entries []EntryResponse, taskID int) {
+	app.runBatch("Reassign", entries, func(entry EntryResponse) error {
+		return app.putEntry(EntryUpdate{ID: entry.ID, Date: entry.Date, TaskID: &taskID})
+	})
+}
+
+func (app *App) bulkToggleBillable(entries []EntryResponse) {
+	app.runBatch("Billable", entries, func(entry EntryResponse) error {
+		billable := 1
+		if entry.Billable > 0 {
+			billable = 0
+		}
+		return app.putEntry(EntryUpdate{ID: entry.ID, Date: entry.Date, Billable: &billable})
+	})
+}
+
+func (app *App) bulkShift(entries []EntryResponse, offset time.Duration) {
+	app.runBatch("Shift", entries, func(entry EntryResponse) error {
+		if app.isEntryTimer(entry) {
+			return fmt.Errorf("timer is running")
+		}
+		start, err1 := time.Parse("15:04:05", entry.StartTime)
+		end, err2 := time.Parse("15:04:05", entry.EndTime)
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("invalid times")
+		}
+		start = start.Add(offset)
+		end = end.Add(offset)
+		if start.Day() != 1 || end.Day() != 1 {
+			return fmt.Errorf("would cross midnight")
+		}
+		return app.putEntry(EntryUpdate{
+			ID:        entry.ID,
+			Date:      entry.Date,
+			StartTime: start.Format("15:04:05"),
+			EndTime:   end.Format("15:04:05"),
+			Duration:  int(end.Sub(start).Seconds()),
+		})
+	})
+}
+
+func (app *App) bulkMove(entries []EntryResponse, date time.Time) {
+	app.runBatch("Move", entries, func(entry EntryResponse) error {
+		if app.isEntryTimer(entry) {
+			return fmt.Errorf("timer is running")
+		}
+		return app.putEntry(EntryUpdate{
+			ID:        entry.ID,
+			Date:      date.Format("2006-01-02"),
+			StartTime: entry.StartTime,
+			EndTime:   entry.EndTime,
+		})
+	})
+}
+
+// parseOffset accepts Go durations ("+15m", "-1h30m") or a bare number of minutes
+func parseOffset(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	if minutes, err := strconv.Atoi(input); err == nil {
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	return time.ParseDuration(strings.TrimPrefix(input, "+"))
+}
+
+// parseTargetDate accepts YYYY-MM-DD, MM-DD in the selected year, or a relative day offset ("+1", "-7")
+func (app *App) parseTargetDate(input string) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	loc := app.selectedDate.Location()
+	if strings.HasPrefix(input, "+") || strings.HasPrefix(input, "-") {
+		days, err := strconv.Atoi(input)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return app.selectedDate.AddDate(0, 0, days), nil
+	}
+	if date, err := time.ParseInLocation("2006-01-02", input, loc); err == nil {
+		return date, nil
+	}
+	date, err := time.ParseInLocation("01-02", input, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD, MM-DD or ±days")
+	}
+	return time.Date(app.selectedDate.Year(), date.Month(), date.Day(), 0, 0, 0, 0, loc), nil
+}
+
+func (app *App) handleBulkInputKeys(key vaxis.Key) {
+	if app.pressed("input.cancel") {
+		app.bulkInputMode = BulkInputNone
+		app.bulkInput = ""
+	} else if app.pressed("input.backspace") {
+		if len(app.bulkInput) > 0 {
+			app.bulkInput = app.bulkInput[:len(app.bulkInput)-1]
+		}
+	} else if app.pressed("input.submit") {
+		mode := app.bulkInputMode
+		input := app.bulkInput
+		app.bulkInputMode = BulkInputNone
+		app.bulkInput = ""
+		switch mode {
+		case BulkInputShift:
+			offset, err := parseOffset(input)
+			if err != nil {
+				app.statusMessage = "Invalid offset: " + input
+				return
+			}
+			app.bulkShift(app.targetEntries(), offset)
+		case BulkInputMove:
+			date, err := app.parseTargetDate(input)
+			if err != nil {
+				app.statusMessage = "Invalid date: " + err.Error()
+				return
+			}
+			app.bulkMove(app.targetEntries(), date)
+		case BulkInputTagFilter:
+			app.tagFilter = strings.TrimSpace(input)
+		case BulkInputTextFilter:
+			app.textFilter = strings.TrimSpace(input)
+		case BulkInputSplit:
+			if app.hasVisibleSelection() {
+				app.splitEntry(app.entries[app.selectedEntry], input)
+			}
+		}
+	} else if key.Text != "" {
+		app.bulkInput += key.Text
+	}
+}
+
+func (app *App) bulkHeaderSegments() []vaxis.Segment {
+	style := app.style("prompt")
+	switch {
+	case app.batch != nil:
+		return []vaxis.Segment{{
+			Text:  fmt.Sprintf("  %s %d/%d ", app.batch.label, app.batch.done, app.batch.total),
+			Style: style,
+		}, {
+			Text:  progressBar(float64(app.batch.done)/float64(app.batch.total), 10),
+			Style: style,
+		}}
+	case app.bulkInputMode == BulkInputShift:
+		return []vaxis.Segment{{Text: "  Shift by: " + app.bulkInput, Style: style}}
+	case app.bulkInputMode == BulkInputTextFilter:
+		return []vaxis.Segment{{Text: "  Filter description: " + app.bulkInput, Style: style}}
+	case app.bulkInputMode == BulkInputTagFilter:
+		return []vaxis.Segment{{Text: "  Filter by tag: " + app.bulkInput, Style: style}}
+	case app.bulkInputMode == BulkInputSplit:
+		return []vaxis.Segment{{Text: "  Split at: " + app.bulkInput, Style: style}}
+	case app.bulkInputMode == BulkInputMove:
+		return []vaxis.Segment{{Text: "  Move to date: " + app.bulkInput, Style: style}}
+	case app.hasMarkedEntries():
+		count := len(app.markedIndexes(app.visibleEntryIndexes()))
+		text := fmt.Sprintf("  %d marked", count)
+		if app.visualAnchor >= 0 {
+			text += " (visual)"
+		}
+		return []vaxis.Segment{{Text: text, Style: style}}
+	}
+	return nil
+}
@@ -11,7 +11,7 @@ func (app *App) drawCalendarWindow(win vaxis.Window) {
 	monthTitle := fmt.Sprintf("%s %d", app.currentMonth.Month().String(), app.currentMonth.Year())
 	win.Println(0, vaxis.Segment{
 		Text:  monthTitle,
-		Style: vaxis.Style{Attribute: vaxis.AttrBold},
+		Style: app.style("title"),
 	})
 
 	daysOfWeek := make([]string, 7)
@@ -31,6 +31,12 @@ func (app *App) drawCalendarWindow(win vaxis.Window) {
 			})
 		}
 	}
+	if app.pendingCopy != nil {
+		win.Println(1, vaxis.Segment{
+			Text:  fmt.Sprintf("Copy %d to…", len(app.pendingCopy)),
+			Style: app.style("prompt"),
+		})
+	}
 	win.Println(2, daySegments...)
 
 	firstDay := app.currentMonth
@@ -68,21 +74,29 @@ func (app *App) drawCalendarWindow(win vaxis.Window) {
 				isWeekend := currentDate.Weekday() == time.Saturday || currentDate.Weekday() == time.Sunday
 				style := vaxis.Style{}
 				if isWeekend {
-					style.Foreground = vaxis.IndexColor(250)
+					style.Foreground = app.style("weekend").Foreground
 				}
 				if isCursor && app.focusedWindow == WinCalendar {
 					style.Attribute = vaxis.AttrReverse
 					if isToday {
-						style.Foreground = vaxis.IndexColor(4) // Blue for selected day
+						style.Foreground = app.style("current").Foreground
 					}
 				} else if isSelected {
 					style.Attribute = vaxis.AttrBold
-					style.Foreground = vaxis.IndexColor(4) // Blue for selected day
+					style.Foreground = app.style("current").Foreground
 				} else if isToday {
 					style.Attribute = vaxis.AttrBold
-					// style.Foreground = vaxis.IndexColor(15)
 
 				}
+				day := dayNum
+				app.onMouse(win.New(weekDay*3, row+weekRow, 2, 1), func(col, row int) bool {
+					if !app.canChangeFocus() {
+						return false
+					}
+					app.focusedWindow = WinCalendar
+					app.cursorDay = day
+					return app.runAction("calendar.select")
+				}, nil)
 				dayText := fmt.Sprintf("%2d", dayNum)
 				segments = append(segments, vaxis.Segment{
 					Text:  dayText,
@@ -111,52 +125,57 @@ func (app *App) handleCalendarKeys(key vaxis.Key) bool {
 	year, month, _ := app.currentMonth.Date()
 	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, app.currentMonth.Location()).Day()
 
-	if key.Matches('L') {
+	if app.pressed("calendar.focus_timer") {
 		app.focusedWindow = WinTimer
-	} else if key.Matches('J') {
+	} else if app.pressed("calendar.focus_entries") {
 		app.focusedWindow = WinEntries
-	} else if key.Matches('h') || key.Matches(vaxis.KeyLeft) {
+	} else if app.pressed("calendar.left") {
 		if app.cursorDay > 1 {
 			app.cursorDay--
 		}
-	} else if key.Matches('l') || key.Matches(vaxis.KeyRight) {
+	} else if app.pressed("calendar.right") {
 		if app.cursorDay < daysInMonth {
 			app.cursorDay++
 		}
-	} else if key.Matches('k') || key.Matches(vaxis.KeyUp) {
+	} else if app.pressed("calendar.up") {
 		// Move up a week
 		if app.cursorDay > 7 {
 			app.cursorDay -= 7
 		}
-	} else if key.Matches('j') || key.Matches(vaxis.KeyDown) {
+	} else if app.pressed("calendar.down") {
 		// Move down a week
 		if app.cursorDay+7 <= daysInMonth {
 			app.cursorDay += 7
 		}
-	} else if key.Matches('g') || key.Matches(vaxis.KeyHome) {
+	} else if app.pressed("calendar.first_day") {
 		// First day of month
 		app.cursorDay = 1
-	} else if key.Matches('G') || key.Matches(vaxis.KeyEnd) {
+	} else if app.pressed("calendar.last_day") {
 		// Last day of month
 		app.cursorDay = daysInMonth
-	} else if key.Matches('p') || key.Matches(vaxis.KeyPgUp) {
+	} else if app.pressed("calendar.prev_month") {
 		// Previous month
 		app.currentMonth = time.Date(year, month-1, 1, 0, 0, 0, 0, app.currentMonth.Location())
 		if app.cursorDay > time.Date(app.currentMonth.Year(), app.currentMonth.Month()+1, 0, 0, 0, 0, 0, app.currentMonth.Location()).Day() {
 			app.cursorDay = time.Date(app.currentMonth.Year(), app.currentMonth.Month()+1, 0, 0, 0, 0, 0, app.currentMonth.Location()).Day()
 		}
-	} else if key.Matches('n') || key.Matches(vaxis.KeyPgDown) {
+	} else if app.pressed("calendar.next_month") {
 		// Next month
 		app.currentMonth = time.Date(year, month+1, 1, 0, 0, 0, 0, app.currentMonth.Location())
 		if app.cursorDay > time.Date(app.currentMonth.Year(), app.currentMonth.Month()+1, 0, 0, 0, 0, 0, app.currentMonth.Location()).Day() {
 			app.cursorDay = time.Date(app.currentMonth.Year(), app.currentMonth.Month()+1, 0, 0, 0, 0, 0, app.currentMonth.Location()).Day()
 		}
-	} else if key.Matches('t') {
+	} else if app.pressed("calendar.today") {
 		// Today
 		now := time.Now()
 		app.currentMonth = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 		app.cursorDay = now.Day()
-	} else if key.Matches(vaxis.KeyEnter) || key.Matches(vaxis.KeySpace) {
+	} else if app.pressed("calendar.cancel_copy") && app.pendingCopy != nil {
+		app.cancelCopy()
+	} else if app.pressed("calendar.select") && app.pendingCopy != nil {
+		app.copyEntriesTo(app.pendingCopy, time.Date(year, month, app.cursorDay, 0, 0, 0, 0, app.currentMonth.Location()))
+		app.focusedWindow = WinEntries
+	} else if app.pressed("calendar.select") {
 		app.selectedTask = -1
 		app.selectedDay = app.cursorDay
 		app.selectedDate = time.Date(year, month, app.selectedDay, 0, 0, 0, 0, app.currentMonth.Location())
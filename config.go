@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+type Config struct {
+	RoundMinutes      int                            `json:"round_minutes"`       // Granularity used when rounding times
+	Keys              map[string]map[string][]string `json:"keys"`                // Bindings by scope and action, replacing the defaults
+	Theme             string                         `json:"theme"`               // dark, light or high-contrast
+	ColorMode         string                         `json:"color_mode"`          // auto, truecolor or 16
+	Colors            map[string]styleSpec           `json:"colors"`              // Styles by role, replacing the theme's
+	Layout            string                         `json:"layout"`              // auto, standard, wide or stacked
+	IdleMinutes       int                            `json:"idle_minutes"`        // Inactivity that counts as idle while the timer runs, 0 (default) disables
+	IdleCommand       string                         `json:"idle_command"`        // Prints the system idle time in milliseconds
+	FocusMinutes      int                            `json:"focus_minutes"`       // Length of a focus session work block
+	BreakMinutes      int                            `json:"break_minutes"`       // Length of the break after each work block
+	FocusEnd          string                         `json:"focus_end"`           // stop or switch, what the timer does when a block ends
+	BreakTask         int                            `json:"break_task"`          // Task timed during breaks with focus_end "switch"
+	DailyTargetHours  float64                        `json:"daily_target_hours"`  // Hours to log each day, 0 to hide
+	WeeklyTargetHours float64                        `json:"weekly_target_hours"` // Hours to log each week, 0 to hide
+}
+
+func defaultConfig() Config {
+	return Config{
+		RoundMinutes: 1,
+		Theme:        "dark",
+		ColorMode:    "auto",
+		Layout:       "auto",
+		FocusMinutes: 25,
+		BreakMinutes: 5,
+		FocusEnd:     "stop",
+	}
+}
+
+func configPath() (string, error) {
+	if path := os.Getenv("TUICAMP_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "tuicamp", "config.json"), nil
+}
+
+func loadConfig() (Config, error) {
+	config := defaultConfig()
+	path, err := configPath()
+	if err != nil {
+		return config, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return config, fmt.Errorf("error reading config: %w", err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+	if _, ok := layoutNames[config.Layout]; !ok && config.Layout != "auto" {
+		return config, fmt.Errorf("unknown layout %q in %s", config.Layout, path)
+	}
+	if config.FocusEnd != "stop" && config.FocusEnd != "switch" {
+		return config, fmt.Errorf("focus_end must be \"stop\" or \"switch\" in %s", path)
+	}
+	if config.FocusMinutes <= 0 || config.BreakMinutes <= 0 {
+		return config, fmt.Errorf("focus_minutes and break_minutes must be positive in %s", path)
+	}
+	return config, nil
+}
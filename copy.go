@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// startCopy remembers the entries to clone and hands focus to the calendar so
+// the user can pick the target date
+func (app *App) startCopy(entries []EntryResponse) {
+	var copyable []EntryResponse
+	for _, entry := range entries {
+		if !app.isEntryTimer(entry) {
+			copyable = append(copyable, entry)
+		}
+	}
+	if len(copyable) == 0 {
+		app.statusMessage = "Nothing to copy"
+		return
+	}
+	app.pendingCopy = copyable
+	app.clearMarks()
+	app.focusedWindow = WinCalendar
+}
+
+func (app *App) cancelCopy() {
+	app.pendingCopy = nil
+}
+
+func (app *App) copyEntriesTo(entries []EntryResponse, date time.Time) {
+	app.pendingCopy = nil
+	go func() {
+		targetEntries, err := app.fetchEntriesRange(date, date)
+		if err != nil {
+			app.statusMessage = "Copy failed: " + err.Error()
+			app.vx.PostEvent(vaxis.Redraw{})
+			return
+		}
+		var failures, overlaps []string
+		app.beginJournalGroup()
+		for _, entry := range entries {
+			entry.Date = date.Format("2006-01-02")
+			entry.ID = 0 // A new entry, which may overlap its own source on the same day
+			for _, existing := range targetEntries {
+				if entriesOverlap(entry, existing) {
+					overlaps = append(overlaps, fmt.Sprintf("%s-%s", existing.StartTime, existing.EndTime))
+				}
+			}
+			if _, err := app.createEntry(entry); err != nil {
+				failures = append(failures, fmt.Sprintf("%s (%v)", entry.StartTime, err))
+			}
+		}
+		app.endJournalGroup()
+
+		message := fmt.Sprintf("Copied %d of %d entries to %s", len(entries)-len(failures), len(entries), date.Format("Jan 2"))
+		if len(overlaps) > 0 {
+			message += "; overlaps " + strings.Join(overlaps, ", ")
+		}
+		if len(failures) > 0 {
+			message += "; failed " + strings.Join(failures, ", ")
+		}
+		app.statusMessage = message
+		app.selectedDay = date.Day()
+		app.selectedDate = date
+		app.fetchEntries(app.selectedDate)
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
+
+// entriesOverlap reports whether two entries on the same date share any time
+func entriesOverlap(a, b EntryResponse) bool {
+	if a.Date != b.Date || a.ID == b.ID {
+		return false
+	}
+	aStart, aEnd, ok := entryTimes(a)
+	if !ok {
+		return false
+	}
+	bStart, bEnd, ok := entryTimes(b)
+	if !ok {
+		return false
+	}
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+func entryTimes(entry EntryResponse) (time.Time, time.Time, bool) {
+	start, err1 := time.Parse("15:04:05", entry.StartTime)
+	end, err2 := time.Parse("15:04:05", entry.EndTime)
+	return start, end, err1 == nil && err2 == nil
+}
@@ -1,37 +1,49 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
 )
 
 type EntryResponse struct {
-	ID               int64  `json:"id"`
-	Duration         string `json:"duration"`
-	UserID           string `json:"user_id"`
-	UserName         string `json:"user_name"`
-	TaskID           string `json:"task_id"`
-	TaskNote         string `json:"task_note"`
-	LastModify       string `json:"last_modify"`
-	Date             string `json:"date"`
-	StartTime        string `json:"start_time"`
-	EndTime          string `json:"end_time"`
-	Locked           string `json:"locked"`
-	Name             string `json:"name"`
-	AddonsExternalID string `json:"addons_external_id"`
-	Billable         int    `json:"billable"`
-	InvoiceID        string `json:"invoiceId"`
-	Color            string `json:"color"`
-	Description      string `json:"description"`
+	ID               int64      `json:"id"`
+	Duration         string     `json:"duration"`
+	UserID           string     `json:"user_id"`
+	UserName         string     `json:"user_name"`
+	TaskID           string     `json:"task_id"`
+	TaskNote         string     `json:"task_note"`
+	LastModify       string     `json:"last_modify"`
+	Date             string     `json:"date"`
+	StartTime        string     `json:"start_time"`
+	EndTime          string     `json:"end_time"`
+	Locked           string     `json:"locked"`
+	Name             string     `json:"name"`
+	AddonsExternalID string     `json:"addons_external_id"`
+	Billable         int        `json:"billable"`
+	InvoiceID        string     `json:"invoiceId"`
+	Color            string     `json:"color"`
+	Description      string     `json:"description"`
+	Tags             []EntryTag `json:"tags"`
 }
 
 func (app *App) drawEntriesWindow(win vaxis.Window) {
 	if app.showDeleteConfirm {
-		app.drawConfirmationDialog(win, "Delete this entry? (y/n)", 1)
+		message := "Delete this entry? (y/n)"
+		if app.hasMarkedEntries() {
+			message = fmt.Sprintf("Delete %d entries? (y/n)", len(app.targetEntries()))
+		}
+		app.drawConfirmationDialog(win, message, "danger")
+		return
+	}
+	if app.showEditEntry && app.showTaskDetail {
+		app.drawTaskDetail(win)
+		app.fetchQueuedTaskSpent()
 		return
 	}
 	if app.showEditEntry {
@@ -42,7 +54,7 @@ func (app *App) drawEntriesWindow(win vaxis.Window) {
 	if app.entries == nil {
 		win.Print(vaxis.Segment{
 			Text:  "Loading entries...",
-			Style: vaxis.Style{Attribute: vaxis.AttrItalic},
+			Style: app.style("loading"),
 		})
 		return
 	}
@@ -50,35 +62,46 @@ func (app *App) drawEntriesWindow(win vaxis.Window) {
 	if app.selectedDay == 0 {
 		win.Print(vaxis.Segment{
 			Text:  "No date selected",
-			Style: vaxis.Style{Attribute: vaxis.AttrItalic},
+			Style: app.style("loading"),
 		})
 		return
 	}
 
+	if app.groupedView {
+		app.drawGroupedEntries(win)
+		return
+	}
+
 	dateStr := app.selectedDate.Format("Monday, January 2, 2006")
-	win.Println(0, vaxis.Segment{
+	header := [][]vaxis.Segment{append([]vaxis.Segment{{
 		Text:  dateStr,
-		Style: vaxis.Style{Attribute: vaxis.AttrBold},
-	})
-
-	scrollOffset := 0
-
-	_, rows := app.vx.Window().Size()
+		Style: app.style("title"),
+	}, {
+		Text:  app.filterHeaderText(),
+		Style: app.style("accent"),
+	}}, app.bulkHeaderSegments()...), nil}
 
 	containsBillable := slices.ContainsFunc(app.entries, func(entry EntryResponse) bool {
 		return entry.Billable > 0
 	})
-	visibleEntries := calculateVisibleEntries(app.entries, scrollOffset, rows)
+	containsLocked := slices.ContainsFunc(app.entries, isEntryLocked)
+	app.ensureVisibleSelection()
+	overlapping := app.overlappingEntries()
+	gapsDrawn := map[time.Time]bool{}
 	var totalDuration time.Duration
-	for i, entry := range visibleEntries {
-		row := i + 2 // +1 to account for title row
+	var rows [][]vaxis.Segment
+	var rowEntries []int // Entry index of each row, -1 for gaps and the carried timer
+	cursor := -1
+	visible := app.visibleEntryIndexes()
+	marked := app.markedIndexes(visible)
+	for _, index := range visible {
+		entry := app.entries[index]
 		isTimer := app.isEntryTimer(entry)
-		hexValue, _ := strconv.ParseUint(entry.Color[1:], 16, 32)
 		seconds, _ := strconv.ParseInt(entry.Duration, 10, 64)
 		duration := "0s"
 		if seconds == 0 && entry.StartTime == entry.EndTime {
 			givenTime, _ := time.ParseInLocation("2006-01-02 15:04:05", entry.Date+" "+entry.StartTime, app.selectedDate.Location())
-			elapsedTime := time.Since(givenTime)
+			elapsedTime := elapsedOnDate(givenTime)
 			totalDuration += elapsedTime.Round(time.Second)
 			duration = elapsedTime.Round(time.Second).String()
 		} else {
@@ -87,10 +110,8 @@ func (app *App) drawEntriesWindow(win vaxis.Window) {
 			duration = elapsedTime.String()
 		}
 		selectedStyle := vaxis.Style{}
-		if i+app.entriesCursor == app.selectedEntry && app.focusedWindow == WinEntries {
-			selectedStyle = vaxis.Style{
-				Attribute: vaxis.AttrReverse,
-			}
+		if index == app.selectedEntry && app.focusedWindow == WinEntries {
+			selectedStyle = app.style("selection")
 		}
 		endTime := " - " + entry.EndTime
 		if isTimer {
@@ -105,53 +126,95 @@ func (app *App) drawEntriesWindow(win vaxis.Window) {
 		} else if containsBillable {
 			name = "  " + name
 		}
-		win.Println(row,
-			vaxis.Segment{
-				Text: "● ",
-				Style: vaxis.Style{
-					Foreground: vaxis.HexColor(uint32(hexValue)),
-					Attribute:  vaxis.AttrBold,
-				},
+		if isEntryLocked(entry) {
+			name = " 🔒" + name
+		} else if containsLocked {
+			name = "   " + name
+		}
+		markText := ""
+		if app.hasMarkedEntries() {
+			markText = "  "
+			if marked[index] {
+				markText = "✓ "
+			}
+		}
+		dot := vaxis.Segment{
+			Text: "● ",
+			Style: vaxis.Style{
+				Foreground: app.theme.hex(entry.Color),
+				Attribute:  vaxis.AttrBold,
+			},
+		}
+		if overlapping[entry.ID] {
+			dot = vaxis.Segment{
+				Text:  "! ",
+				Style: app.style("error"),
+			}
+		}
+		segments := []vaxis.Segment{
+			{
+				Text:  markText,
+				Style: app.style("marked"),
 			},
+			dot,
 			vaxis.Segment{
-				Text: fmt.Sprintf("%-10s", duration),
-				Style: vaxis.Style{
-					Attribute: selectedStyle.Attribute,
-				},
+				Text:  fmt.Sprintf("%-10s", duration),
+				Style: selectedStyle,
 			},
 			vaxis.Segment{
-				Text: entry.StartTime,
-				Style: vaxis.Style{
-					Attribute: selectedStyle.Attribute,
-				},
+				Text:  entry.StartTime,
+				Style: selectedStyle,
 			},
 			vaxis.Segment{
-				Text: endTime,
-				Style: vaxis.Style{
-					Attribute: selectedStyle.Attribute,
-				},
+				Text:  endTime,
+				Style: selectedStyle,
 			},
 			vaxis.Segment{
-				Text: name,
-				Style: vaxis.Style{
-					Attribute: selectedStyle.Attribute,
-				},
+				Text:  name,
+				Style: selectedStyle,
 			},
 			vaxis.Segment{
 				Text: " " + entry.Description,
 			},
-		)
+		}
+		if index == app.selectedEntry {
+			cursor = len(rows)
+		}
+		rows = append(rows, append(segments, app.tagSegments(entry.Tags)...))
+		rowEntries = append(rowEntries, index)
+		if gap, ok := app.gapAfter(entry); ok && !gapsDrawn[gap.Start] {
+			gapsDrawn[gap.Start] = true
+			rows = append(rows, gapRowSegments(gap, markText != ""))
+			rowEntries = append(rowEntries, -1)
+		}
 	}
-	if len(app.entries) > 0 {
-		win.Println(len(app.entries)+3,
-			vaxis.Segment{
-				Text: "Total " + totalDuration.String(),
-				Style: vaxis.Style{
-					Attribute: vaxis.AttrBold,
-				},
+	if startedAt, elapsed, ok := app.carriedTimer(); ok && (!app.isFiltered() || app.timerFilter) {
+		rows = append(rows, carriedTimerSegments(startedAt, elapsed, app.hasMarkedEntries()))
+		rowEntries = append(rowEntries, -1)
+		totalDuration += elapsed
+	}
+	var footer [][]vaxis.Segment
+	if len(rows) > 0 {
+		footer = [][]vaxis.Segment{nil, {{
+			Text: "Total " + totalDuration.String(),
+			Style: vaxis.Style{
+				Attribute: vaxis.AttrBold,
 			},
-		)
+		}}}
 	}
+	app.entriesList.draw(win, header, rows, footer, cursor)
+	app.onListMouse(&app.entriesList, func(row int) bool {
+		index := rowEntries[row]
+		if index < 0 || app.bulkInputMode != BulkInputNone {
+			return false
+		}
+		app.focusedWindow = WinEntries
+		if index == app.selectedEntry {
+			return app.runAction("entries.edit")
+		}
+		app.selectedEntry = index
+		return false
+	}, app.moveSelection)
 }
 
 func (app *App) handleContentKeys(key vaxis.Key) bool {
@@ -159,69 +222,169 @@ func (app *App) handleContentKeys(key vaxis.Key) bool {
 		return false
 	}
 	if app.showDeleteConfirm {
-		if key.Matches('y') || key.Matches(vaxis.KeyEnter) {
+		if app.pressed("dialog.confirm") {
 			app.showDeleteConfirm = false
-			app.deleteEntry(app.entries[app.selectedEntry].ID)
-			app.fetchEntries(app.selectedDate)
+			if app.hasMarkedEntries() {
+				app.bulkDelete(app.targetEntries())
+				return false
+			}
+			if app.hasVisibleSelection() {
+				app.deleteEntry(app.entries[app.selectedEntry].ID)
+				app.fetchEntries(app.selectedDate)
+			}
 			return false
-		} else if key.Matches('n') || key.Matches(vaxis.KeyEsc) {
+		} else if app.pressed("dialog.cancel") {
 			app.showDeleteConfirm = false
 		}
+		return false
 	}
 	if app.showEditEntry {
 		app.handleEditEntryKeys(key)
 		return false
 	}
+	if app.bulkInputMode != BulkInputNone {
+		app.handleBulkInputKeys(key)
+		return false
+	}
+	if app.groupedView && app.handleGroupedKeys(key) {
+		return false
+	}
 
-	if key.Matches('K') {
+	if app.pressed("entries.focus_calendar") {
 		app.focusedWindow = WinCalendar
-	} else if key.Matches('j') || key.Matches(vaxis.KeyDown) {
-		if app.selectedEntry < len(app.entries)-1 {
-			app.selectedEntry++
-			visibleRows := app.entriesRows - 3 // Account for header and footer
-			if app.selectedEntry >= app.entriesCursor+visibleRows {
-				app.entriesCursor = app.selectedEntry - visibleRows + 1
-			}
+	} else if app.pressed("entries.down") {
+		app.moveSelection(1)
+	} else if app.pressed("entries.up") {
+		app.moveSelection(-1)
+	} else if app.pressed("entries.page_down") {
+		app.moveSelection(app.entriesList.page())
+	} else if app.pressed("entries.page_up") {
+		app.moveSelection(-app.entriesList.page())
+	} else if app.pressed("entries.tag_filter") {
+		app.bulkInputMode = BulkInputTagFilter
+		app.bulkInput = app.tagFilter
+	} else if app.pressed("entries.text_filter") {
+		app.bulkInputMode = BulkInputTextFilter
+		app.bulkInput = app.textFilter
+	} else if app.pressed("entries.billable_filter") {
+		app.billableFilter = !app.billableFilter
+	} else if app.pressed("entries.timer_filter") {
+		app.timerFilter = !app.timerFilter
+	} else if app.pressed("entries.task_filter") {
+		if app.taskFilter != 0 {
+			app.taskFilter = 0
+		} else if app.hasVisibleSelection() {
+			app.openTaskPicker(TaskPickFilter, app.entries[app.selectedEntry].TaskID)
 		}
-	} else if key.Matches('k') || key.Matches(vaxis.KeyUp) {
-		if app.selectedEntry > 0 {
-			app.selectedEntry--
-			if app.selectedEntry < app.entriesCursor {
-				app.entriesCursor = app.selectedEntry
-			}
+	} else if app.pressed("entries.clear_filters") {
+		app.clearFilters()
+	} else if app.pressed("entries.sort") {
+		app.entrySort = (app.entrySort + 1) % len(sortNames)
+	} else if app.pressed("entries.reverse_sort") {
+		app.entrySortReverse = !app.entrySortReverse
+	} else if app.pressed("entries.toggle_view") {
+		app.toggleGroupedView()
+	} else if app.pressed("entries.delete") {
+		if !app.hasMarkedEntries() && !app.hasVisibleSelection() {
+			return false
+		}
+		if !app.hasMarkedEntries() && isEntryLocked(app.entries[app.selectedEntry]) {
+			app.statusMessage = "Cannot delete: " + lockedError(app.entries[app.selectedEntry]).Error()
+			return false
 		}
-	} else if key.Matches('d') {
 		app.showDeleteConfirm = true
-	} else if key.Matches('e') || key.Matches(vaxis.KeyEnter) {
+	} else if app.pressed("entries.edit") && app.hasVisibleSelection() {
 		app.showEditEntry = true
 		app.entryEditCursor = 0
 		app.entryTimeInitialized = false
+		app.timeFieldCursor = len(app.entries[app.selectedEntry].StartTime)
+		if isEntryLocked(app.entries[app.selectedEntry]) {
+			app.entryEditCursor = -1 // Nothing to edit
+		}
+	} else if app.pressed("entries.continue") && app.hasVisibleSelection() {
+		app.continueEntry(app.entries[app.selectedEntry])
+	} else if app.pressed("entries.copy") {
+		app.startCopy(app.targetEntries())
+	} else if app.pressed("entries.copy_day") {
+		app.startCopy(app.entries)
+	} else if app.pressed("entries.undo") {
+		app.undo()
+	} else if app.pressed("entries.redo") {
+		app.redo()
+	} else if app.pressed("entries.clear_marks") {
+		app.clearMarks()
+	} else if !app.hasVisibleSelection() || app.batch != nil {
+		return false
+	} else if app.pressed("entries.mark") {
+		app.toggleMark()
+		app.moveSelection(1)
+	} else if app.pressed("entries.visual") {
+		app.toggleVisual()
+	} else if app.pressed("entries.reassign") {
+		app.openTaskPicker(TaskPickReassign, app.entries[app.selectedEntry].TaskID)
+	} else if app.pressed("entries.billable") {
+		app.bulkToggleBillable(app.targetEntries())
+	} else if app.pressed("entries.shift") {
+		app.bulkInputMode = BulkInputShift
+		app.bulkInput = ""
+	} else if app.pressed("entries.move") {
+		app.bulkInputMode = BulkInputMove
+		app.bulkInput = ""
+	} else if app.pressed("entries.split") {
+		app.bulkInputMode = BulkInputSplit
+		app.bulkInput = ""
+	} else if app.pressed("entries.merge") {
+		app.mergeEntries(app.targetEntries())
+	} else if app.pressed("entries.fill_gap") {
+		app.startFillGap()
 	}
 	return false
 }
 
 func (app *App) fetchEntries(date time.Time) error {
+	allEntries, err := app.fetchEntriesRange(date, date)
+	if err != nil {
+		return err
+	}
+	app.entries = allEntries
+	app.selectedEntry = 0
+	app.entriesList.offset = 0
+	if app.hasTargets() {
+		go app.fetchProgressEntries()
+	}
+	if app.groupWeek {
+		return app.fetchRangeEntries()
+	}
+	return nil
+}
+
+func (app *App) fetchEntriesRange(from, to time.Time) ([]EntryResponse, error) {
 	var allEntries []EntryResponse
-	year := date.Year()
-	month := int(date.Month())
-	day := date.Day()
 	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
-		Endpoint: fmt.Sprintf("/entries?from=%d-%02d-%02d&to=%d-%02d-%02d", year, month, day, year, month, day),
+		Endpoint: fmt.Sprintf("/entries?from=%s&to=%s&opt_fields=tags", from.Format("2006-01-02"), to.Format("2006-01-02")),
 		Method:   "GET",
 		Response: &allEntries,
 		Headers:  map[string]string{"Authorization": "Bearer " + app.apiToken},
 	})
 	result := <-resultChan
 	if result.Error != nil {
-		return fmt.Errorf("failed API response: %w", result.Error)
+		return nil, fmt.Errorf("failed API response: %w", result.Error)
 	}
-	app.entries = allEntries
-	app.selectedEntry = 0
-	app.entriesCursor = 0
-	return nil
+	return allEntries, nil
 }
 
 func (app *App) deleteEntry(ID int64) error {
+	before, found := app.findEntryByID(ID)
+	if err := app.sendDeleteEntry(ID); err != nil {
+		return err
+	}
+	if found {
+		app.recordJournal(journalOp{kind: JournalDelete, before: before})
+	}
+	return nil
+}
+
+func (app *App) sendDeleteEntry(ID int64) error {
 	type Body struct {
 		ID string `json:"id"`
 	}
@@ -243,10 +406,90 @@ func (app *App) deleteEntry(ID int64) error {
 	if result.Error != nil {
 		return fmt.Errorf("failed API response: %w", result.Error)
 	}
+	app.invalidateTaskSpent()
 	return nil
 }
 
-func calculateVisibleEntries(entries []EntryResponse, scrollOffset, maxVisible int) []EntryResponse {
+func (app *App) createEntry(entry EntryResponse) (int64, error) {
+	ID, err := app.sendCreateEntry(entry)
+	if ID == 0 {
+		return 0, err
+	}
+	entry.ID = ID
+	app.recordJournal(journalOp{kind: JournalCreate, after: entry})
+	return ID, err
+}
+
+func (app *App) sendCreateEntry(entry EntryResponse) (int64, error) {
+	type Body struct {
+		Date        string `json:"date"`
+		StartTime   string `json:"start_time"`
+		EndTime     string `json:"end_time"`
+		Duration    int    `json:"duration,omitempty"`
+		TaskID      string `json:"task_id,omitempty"`
+		Description string `json:"description,omitempty"`
+		Billable    int    `json:"billable"`
+	}
+	type Response struct {
+		EntryID json.Number `json:"entry_id"`
+	}
+	body := Body{
+		Date:        entry.Date,
+		StartTime:   entry.StartTime,
+		EndTime:     entry.EndTime,
+		Duration:    entryDuration(entry),
+		TaskID:      entry.TaskID,
+		Description: entry.Description,
+		Billable:    entry.Billable,
+	}
+	var response Response
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint:    "/entries",
+		Method:      "POST",
+		RequestBody: &body,
+		Response:    &response,
+		Headers:     map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed API response: %w", result.Error)
+	}
+	app.invalidateTaskSpent()
+	ID, _ := response.EntryID.Int64()
+	// Tags are not part of the entry body and are added once the entry exists
+	if len(entry.Tags) > 0 {
+		tagIDs := make([]string, 0, len(entry.Tags))
+		for _, tag := range entry.Tags {
+			tagIDs = append(tagIDs, tag.TagID.String())
+		}
+		if err := app.changeEntryTags(ID, "POST", tagIDs); err != nil {
+			return ID, err
+		}
+	}
+	return ID, nil
+}
+
+func (app *App) findEntryByID(ID int64) (EntryResponse, bool) {
+	for _, entry := range app.entries {
+		if entry.ID == ID {
+			return entry, true
+		}
+	}
+	return EntryResponse{}, false
+}
+
+// entryDuration returns the entry length in seconds computed from its start and end times
+func entryDuration(entry EntryResponse) int {
+	start, err1 := time.Parse("15:04:05", entry.StartTime)
+	end, err2 := time.Parse("15:04:05", entry.EndTime)
+	if err1 != nil || err2 != nil {
+		seconds, _ := strconv.Atoi(entry.Duration)
+		return seconds
+	}
+	return int(end.Sub(start).Seconds())
+}
+
+func calculateVisibleEntries[T any](entries []T, scrollOffset, maxVisible int) []T {
 	if scrollOffset < 0 {
 		scrollOffset = 0
 	}
@@ -257,6 +500,15 @@ func calculateVisibleEntries(entries []EntryResponse, scrollOffset, maxVisible i
 	return entries[scrollOffset:end]
 }
 
+func progressBar(ratio float64, width int) string {
+	if width < 1 {
+		return ""
+	}
+	filled := int(ratio*float64(width) + 0.5)
+	filled = max(0, min(filled, width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -11,9 +11,18 @@ import (
 const (
 	EntryCursorStart = iota
 	EntryCursorEnd
+	EntryCursorDuration
+	EntryCursorTags
 	EntryCursorTask
 )
 
+const ( // What the task picker is choosing a task for
+	TaskPickNone     = iota // Editing a single entry
+	TaskPickReassign        // Reassigning the marked entries
+	TaskPickFillGap         // Creating an entry for a gap
+	TaskPickFilter          // Filtering the entries list by task subtree
+)
+
 func (app *App) isEntryTimer(entry EntryResponse) bool {
 	return entry.StartTime == entry.EndTime && len(app.timers) > 0
 }
@@ -29,65 +38,96 @@ func (app *App) drawEditEntryWindow(win vaxis.Window) {
 	if app.entryEndTime == "" && currentEntry.EndTime != "" && !app.entryTimeInitialized {
 		app.entryEndTime = currentEntry.EndTime
 	}
+	if !app.entryTimeInitialized {
+		app.initEntryTags(currentEntry)
+	}
 	app.entryTimeInitialized = true
 
 	win.Println(0, vaxis.Segment{
 		Text:  dateStr,
 		Style: vaxis.Style{Attribute: vaxis.AttrBold},
 	})
+	if app.taskPickMode != TaskPickNone {
+		app.drawTaskPickHeader(win)
+		app.drawTaskPicker(win, currentEntry, 4)
+		return
+	}
 	isValid := app.validateTimes()
 	startTimeStyle := vaxis.Style{}
-	if app.entryEditCursor == EntryCursorStart {
-		startTimeStyle.Attribute |= vaxis.AttrReverse
-	}
 	if !isValid && app.entryStartTime != "" {
-		startTimeStyle.Foreground = vaxis.IndexColor(1) // Red for invalid
+		startTimeStyle = app.style("error")
 	}
-	win.Println(1, vaxis.Segment{
-		Text:  "Start: ",
+	win.Println(1, append([]vaxis.Segment{{
+		Text:  "Start:    ",
 		Style: vaxis.Style{Attribute: vaxis.AttrBold},
-	}, vaxis.Segment{
-		Text:  app.entryStartTime,
-		Style: startTimeStyle,
-	})
+	}}, app.timeFieldSegments(EntryCursorStart, app.entryStartTime, startTimeStyle)...)...)
 	endTimeStyle := vaxis.Style{}
-	if app.entryEditCursor == EntryCursorEnd {
-		endTimeStyle.Attribute |= vaxis.AttrReverse
-	}
 	if !isValid && app.entryEndTime != "" {
-		endTimeStyle.Foreground = vaxis.IndexColor(1) // Red for invalid
+		endTimeStyle = app.style("error")
 	}
-	currentEntryEndTime := app.entryEndTime
+	endSegments := app.timeFieldSegments(EntryCursorEnd, app.entryEndTime, endTimeStyle)
+	durationSegments := app.timeFieldSegments(EntryCursorDuration, app.entryDurationText(), vaxis.Style{})
 	if isTimer {
-		currentEntryEndTime = "⏱ "
+		endSegments = []vaxis.Segment{{Text: "⏱ ", Style: endTimeStyle}}
+		durationSegments = nil
+	} else if isOvernight(app.entryStartTime, app.entryEndTime) {
+		endSegments = append(endSegments, vaxis.Segment{
+			Text:  " (next day)",
+			Style: app.style("muted"),
+		})
 	}
-	win.Println(2, vaxis.Segment{
-		Text:  "End:   ",
+	win.Println(2, append([]vaxis.Segment{{
+		Text:  "End:      ",
 		Style: vaxis.Style{Attribute: vaxis.AttrBold},
-	}, vaxis.Segment{
-		Text:  currentEntryEndTime,
-		Style: endTimeStyle,
-	})
+	}}, endSegments...)...)
+	win.Println(3, append([]vaxis.Segment{{
+		Text:  "Duration: ",
+		Style: vaxis.Style{Attribute: vaxis.AttrBold},
+	}}, durationSegments...)...)
 	currentEntryName := "✕ No task selected"
 	if currentEntry.Name != "" {
 		currentEntryName = currentEntry.Name
 	}
-	win.Println(3, vaxis.Segment{
-		Text:  "Task:  ",
+	tagsStyle := app.style("accent")
+	if app.entryEditCursor == EntryCursorTags {
+		tagsStyle.Attribute = vaxis.AttrReverse
+	}
+	win.Println(4, vaxis.Segment{
+		Text:  "Tags:     ",
+		Style: vaxis.Style{Attribute: vaxis.AttrBold},
+	}, vaxis.Segment{
+		Text:  app.entryTagNames(),
+		Style: tagsStyle,
+	})
+	win.Println(5, vaxis.Segment{
+		Text:  "Task:     ",
 		Style: vaxis.Style{Attribute: vaxis.AttrBold},
 	}, vaxis.Segment{
 		Text: currentEntryName,
 	})
 
+	if isEntryLocked(currentEntry) {
+		app.drawLockedBanner(win, 7, currentEntry)
+		return
+	}
+
 	if currentEntry.TaskID != "" && app.selectedTask == -1 {
 		app.selectedTask = app.findTaskIndex(currentEntry.TaskID)
 		app.vx.PostEvent(vaxis.Redraw{})
 	}
 
-	if app.taskSearchMode {
-		win.Println(4, vaxis.Segment{
+	if app.entryEditCursor == EntryCursorTags {
+		app.drawTagPicker(win, 6)
+		return
+	}
+	app.drawTaskPicker(win, currentEntry, 6)
+}
+
+func (app *App) drawTaskPicker(win vaxis.Window, currentEntry EntryResponse, top int) {
+	if !app.drawTaskPrompt(win, top) && app.taskSearchMode {
+		win.Println(top, vaxis.Segment{
 			Text:  "Search: " + app.taskSearchInput,
-			Style: vaxis.Style{Foreground: vaxis.IndexColor(3)},
+			Style: app.style("prompt"),
 		})
 	}
 
@@ -95,22 +135,7 @@ func (app *App) drawEditEntryWindow(win vaxis.Window) {
 		app.taskHierarchy = app.buildTaskHierarchy()
 	}
 
-	_, rows := win.Size()
-	visibleRows := rows - 6
-	if visibleRows < 1 {
-		visibleRows = 1
-	}
-	scrollOffset := app.selectedTask - visibleRows/2
-	if scrollOffset < 0 {
-		scrollOffset = 0
-	}
-	if scrollOffset > len(app.taskHierarchy.AllTasksIDs)-visibleRows {
-		scrollOffset = max(0, len(app.taskHierarchy.AllTasksIDs)-visibleRows)
-	}
-
-	row := 5
-	drawnTasks := 0
-
+	var rows [][]vaxis.Segment
 	for _, parentID := range app.taskHierarchy.ParentIDs {
 		if parentID == 0 {
 			continue
@@ -119,72 +144,115 @@ func (app *App) drawEditEntryWindow(win vaxis.Window) {
 		if parentTask == nil {
 			continue
 		}
-		if drawnTasks >= scrollOffset && drawnTasks < scrollOffset+visibleRows {
-			parentTaskID := strconv.Itoa(parentTask.TaskID)
-			isCurrent := parentTaskID == currentEntry.TaskID
-			isSelected := drawnTasks == app.selectedTask
-			style := vaxis.Style{Attribute: vaxis.AttrBold}
-			if app.entryEditCursor == EntryCursorTask {
-				if isCurrent {
-					style.Foreground = vaxis.IndexColor(4)
-				}
-				if isSelected {
-					style.Attribute |= vaxis.AttrReverse
-				}
+		parentTaskID := strconv.Itoa(parentTask.TaskID)
+		isCurrent := parentTaskID == currentEntry.TaskID
+		isSelected := len(rows) == app.selectedTask
+		style := vaxis.Style{Attribute: vaxis.AttrBold}
+		if app.entryEditCursor == EntryCursorTask {
+			if isCurrent {
+				style.Foreground = app.style("current").Foreground
+			}
+			if isSelected {
+				style.Attribute |= vaxis.AttrReverse
 			}
-			win.Println(row, vaxis.Segment{
-				Text:  "• " + parentTask.Name,
-				Style: style,
-			})
-			row++
 		}
-		drawnTasks++
+		rows = append(rows, app.taskRowSegments(parentTask, "", style))
 
 		children := app.taskHierarchy.ParentTasks[parentID]
 		for childIndex, child := range children {
-			if drawnTasks >= scrollOffset && drawnTasks < scrollOffset+visibleRows {
-				childID := strconv.Itoa(child.TaskID)
-				isCurrent := childID == currentEntry.TaskID
-				isSelected := drawnTasks == app.selectedTask
-				style := vaxis.Style{}
-				if app.entryEditCursor == EntryCursorTask {
-					if isCurrent {
-						style.Foreground = vaxis.IndexColor(4)
-					}
-					if isSelected {
-						style.Attribute = vaxis.AttrReverse
-					}
+			childID := strconv.Itoa(child.TaskID)
+			isCurrent := childID == currentEntry.TaskID
+			isSelected := len(rows) == app.selectedTask
+			style := vaxis.Style{}
+			if app.entryEditCursor == EntryCursorTask {
+				if isCurrent {
+					style.Foreground = app.style("current").Foreground
 				}
-				branch := "└─"
-				if childIndex < len(children)-1 {
-					branch = "├─"
+				if isSelected {
+					style.Attribute = vaxis.AttrReverse
 				}
-				win.Println(row, vaxis.Segment{
-					Text:  "  " + branch + " " + child.Name,
-					Style: style,
-				})
-				row++
 			}
-			drawnTasks++
+			branch := "└─"
+			if childIndex < len(children)-1 {
+				branch = "├─"
+			}
+			rows = append(rows, app.taskRowSegments(&child, "  "+branch+" ", style))
 		}
 	}
-	app.drawnTasks = drawnTasks
+
+	width, height := win.Size()
+	listWin := win.New(0, top+1, width, max(1, height-top-2))
+	app.taskList.draw(listWin, nil, rows, nil, app.selectedTask)
+	app.onListMouse(&app.taskList, func(row int) bool {
+		if app.entryEditCursor < 0 || app.taskSearchMode || app.taskInputMode != TaskInputNone {
+			return false
+		}
+		app.commitTimeField()
+		app.entryEditCursor = EntryCursorTask
+		app.selectedTask = row
+		return false
+	}, func(delta int) {
+		if app.entryEditCursor == EntryCursorTask {
+			app.selectedTask = max(0, min(app.selectedTask+delta, len(rows)-1))
+		}
+	})
+	app.drawnTasks = len(rows)
+	app.fetchQueuedTaskSpent()
 }
 
-func (app *App) updateEntry(entryID int64, taskID *int, startTime, endTime string) error {
-	type Body struct {
-		ID        int64  `json:"id"`
-		Date      string `json:"date,omitempty"`
-		StartTime string `json:"start_time,omitempty"`
-		EndTime   string `json:"end_time,omitempty"`
-		Duration  int    `json:"duration,omitempty"`
-		TaskID    *int   `json:"task_id,omitempty"`
+func (app *App) openTaskPicker(mode int, taskID string) {
+	app.showEditEntry = true
+	app.taskPickMode = mode
+	app.entryEditCursor = EntryCursorTask
+	app.selectedTask = max(0, app.findTaskIndex(taskID))
+}
+
+func (app *App) drawTaskPickHeader(win vaxis.Window) {
+	title := ""
+	switch app.taskPickMode {
+	case TaskPickReassign:
+		title = fmt.Sprintf("Reassign %d entries", len(app.targetEntries()))
+	case TaskPickFillGap:
+		title = fmt.Sprintf("Fill gap %s - %s", app.pendingGap.Start.Format("15:04:05"), app.pendingGap.End.Format("15:04:05"))
+	case TaskPickFilter:
+		title = "Show only entries of task"
 	}
-	type Response struct {
-		EntryID string `json:"entry_id"`
-		TaskID  string `json:"task_id"`
+	win.Println(1, vaxis.Segment{
+		Text:  title,
+		Style: vaxis.Style{Attribute: vaxis.AttrBold},
+	})
+	win.Println(3, vaxis.Segment{
+		Text:  "Task:  ",
+		Style: vaxis.Style{Attribute: vaxis.AttrBold},
+	}, vaxis.Segment{
+		Text: "choose below and press Enter",
+	})
+}
+
+func (app *App) applyPickedTask(taskID int) {
+	switch app.taskPickMode {
+	case TaskPickReassign:
+		app.bulkSetTask(app.targetEntries(), taskID)
+	case TaskPickFillGap:
+		app.fillGap(app.pendingGap, taskID)
+	case TaskPickFilter:
+		app.taskFilter = taskID
 	}
-	body := Body{
+}
+
+type EntryUpdate struct {
+	ID          int64   `json:"id"`
+	Date        string  `json:"date,omitempty"`
+	StartTime   string  `json:"start_time,omitempty"`
+	EndTime     string  `json:"end_time,omitempty"`
+	Duration    int     `json:"duration,omitempty"`
+	TaskID      *int    `json:"task_id,omitempty"`
+	Billable    *int    `json:"billable,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+func (app *App) updateEntry(entryID int64, taskID *int, startTime, endTime string) error {
+	body := EntryUpdate{
 		ID:   entryID,
 		Date: app.selectedDate.Format("2006-01-02"),
 	}
@@ -209,6 +277,25 @@ func (app *App) updateEntry(entryID int64, taskID *int, startTime, endTime strin
 			}
 		}
 	}
+	return app.putEntry(body)
+}
+
+func (app *App) putEntry(body EntryUpdate) error {
+	before, found := app.findEntryByID(body.ID)
+	if err := app.sendEntryUpdate(body); err != nil {
+		return err
+	}
+	if found {
+		app.recordJournal(journalOp{kind: JournalUpdate, before: before, update: body})
+	}
+	return nil
+}
+
+func (app *App) sendEntryUpdate(body EntryUpdate) error {
+	type Response struct {
+		EntryID string `json:"entry_id"`
+		TaskID  string `json:"task_id"`
+	}
 	var response Response
 	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
 		Endpoint:    "/entries",
@@ -221,14 +308,16 @@ func (app *App) updateEntry(entryID int64, taskID *int, startTime, endTime strin
 	if result.Error != nil {
 		return fmt.Errorf("failed API response: %w", result.Error)
 	}
+	app.invalidateTaskSpent()
 	return nil
 }
 
 func (app *App) validateTimes() bool {
 	if app.entryStartTime != "" && app.entryEndTime != "" {
-		start, _ := time.Parse("15:04:05", app.entryStartTime)
-		end, _ := time.Parse("15:04:05", app.entryEndTime)
-		if end.Before(start) {
+		// An end before the start is allowed and continues the entry on the next day
+		_, err1 := parseTimeInput(app.entryStartTime)
+		_, err2 := parseTimeInput(app.entryEndTime)
+		if err1 != nil || err2 != nil {
 			return false
 		}
 	}
@@ -240,10 +329,10 @@ func (app *App) handleEditEntryKeys(key vaxis.Key) bool {
 	isTimer := app.isEntryTimer(currentEntry)
 
 	if app.taskSearchMode {
-		if key.Matches(vaxis.KeyEsc) || key.Matches(vaxis.KeyEnter) || key.Matches('/') {
+		if app.pressed("input.cancel") || app.pressed("input.submit") {
 			app.taskSearchMode = false
 			app.taskSearchInput = ""
-		} else if key.Matches(vaxis.KeyBackspace) {
+		} else if app.pressed("input.backspace") {
 			if len(app.taskSearchInput) > 0 {
 				app.taskSearchInput = app.taskSearchInput[:len(app.taskSearchInput)-1]
 				if len(app.taskSearchInput) > 0 {
@@ -263,22 +352,56 @@ func (app *App) handleEditEntryKeys(key vaxis.Key) bool {
 		return false
 	}
 
-	if key.Matches('q') || key.Matches(vaxis.KeyEsc) {
+	if app.showTaskDetail {
+		if app.pressed("detail.close") {
+			app.showTaskDetail = false
+		}
+		return false
+	}
+
+	if app.taskInputMode != TaskInputNone || app.showArchiveConfirm {
+		app.handleTaskManageKeys(key)
+		return false
+	}
+
+	if app.entryEditCursor == EntryCursorTags && app.handleTagPickerKeys(key) {
+		return false
+	}
+
+	if app.pressed("editor.cancel") {
 		app.showEditEntry = false
+		app.taskPickMode = TaskPickNone
 		app.entryStartTime = ""
 		app.entryEndTime = ""
+		app.entryDurationEdited = false
 		app.entryTimeInitialized = false
 		app.selectedTask = -1
 		return false
-	} else if key.Matches(vaxis.KeyTab) {
-		app.entryEditCursor = (app.entryEditCursor + 1) % 3
-		if app.entryEditCursor == EntryCursorEnd && len(app.timers) > 0 {
-			app.entryEditCursor += 1
+	} else if app.taskPickMode == TaskPickNone && isEntryLocked(currentEntry) {
+		return false // Locked entries are read only
+	} else if app.taskPickMode != TaskPickNone && app.pressed("editor.save") {
+		if taskID := app.highlightedTaskID(); taskID != 0 {
+			app.applyPickedTask(taskID)
+		}
+		app.showEditEntry = false
+		app.taskPickMode = TaskPickNone
+		app.selectedTask = -1
+		return false
+	} else if app.taskPickMode != TaskPickNone && app.pressed("editor.next_field") {
+		return false
+	} else if app.pressed("editor.next_field") {
+		app.commitTimeField()
+		cursor := (app.entryEditCursor + 1) % 5
+		if cursor == EntryCursorEnd && len(app.timers) > 0 {
+			cursor = EntryCursorTags
 		}
+		app.enterTimeField(cursor)
 		if app.entryEditCursor == EntryCursorTask && app.selectedTask < 0 {
 			app.selectedTask = 0
 		}
-	} else if key.Matches(vaxis.KeyEnter) || key.Matches(vaxis.KeySpace) {
+		return false
+	} else if app.pressed("editor.save") {
+		app.commitTimeField()
 		if !app.validateTimes() {
 			return false
 		}
@@ -289,12 +412,25 @@ func (app *App) handleEditEntryKeys(key vaxis.Key) bool {
 				taskIDVal := app.taskHierarchy.AllTasksIDs[app.selectedTask]
 				taskID = &taskIDVal
 			}
-			app.updateEntry(
-				app.entries[app.selectedEntry].ID,
-				taskID,
-				app.entryStartTime,
-				app.entryEndTime,
-			)
+			var err error
+			app.beginJournalGroup() // Undone together with the tags below
+			if isOvernight(app.entryStartTime, app.entryEndTime) && !isTimer {
+				err = app.saveOvernightEntry(app.entries[app.selectedEntry], taskID, app.entryStartTime, app.entryEndTime)
+			} else {
+				err = app.updateEntry(
+					app.entries[app.selectedEntry].ID,
+					taskID,
+					app.entryStartTime,
+					app.entryEndTime,
+				)
+			}
+			if err == nil {
+				err = app.saveEntryTags(currentEntry, app.entryTags)
+			}
+			app.endJournalGroup()
+			if err != nil {
+				app.statusMessage = "Save failed: " + err.Error()
+			}
 			app.selectedTask = -1
 			app.entryStartTime = ""
 			app.entryEndTime = ""
@@ -303,110 +439,59 @@ func (app *App) handleEditEntryKeys(key vaxis.Key) bool {
 		}()
 	}
 
-	if app.entryEditCursor == EntryCursorStart || app.entryEditCursor == EntryCursorEnd {
-		currentTime := app.entryStartTime
-		if app.entryEditCursor == EntryCursorEnd {
-			currentTime = app.entryEndTime
-		}
-		if key.Matches('/') {
+	if isTimeField(app.entryEditCursor) {
+		if app.pressed("editor.search") {
+			app.commitTimeField()
 			app.entryEditCursor = EntryCursorTask
 			app.taskSearchMode = true
 			app.taskSearchInput = ""
-		} else if key.Matches(vaxis.KeyDown) {
-			app.entryEditCursor += 1
-		} else if key.Matches(vaxis.KeyUp) {
-			if app.entryEditCursor == EntryCursorEnd {
-				app.entryEditCursor -= 1
+		} else if app.pressed("timefield.next_field") {
+			app.commitTimeField()
+			cursor := app.entryEditCursor + 1
+			if cursor == EntryCursorEnd && isTimer {
+				cursor = EntryCursorTags
 			}
-		} else if key.Matches(vaxis.KeyBackspace) {
-			if len(currentTime) > 0 && !isTimer {
-				if app.entryEditCursor == EntryCursorStart {
-					app.entryStartTime = currentTime[:len(currentTime)-1]
-				} else {
-					app.entryEndTime = currentTime[:len(currentTime)-1]
-				}
-			}
-		} else if key.Text != "" {
-			if key.Text[0] >= '0' && key.Text[0] <= '9' {
-				if len(currentTime) < 8 {
-					newTime := currentTime + key.Text
-					// Automatically add colon after two digits for hours
-					if len(newTime) == 2 {
-						// If we have exactly 2 digits (hours), add a colon
-						newTime += ":"
-					} else if len(newTime) == 5 {
-						// If we have exactly 5 characters (HH:MM), add a colon
-						newTime += ":"
-					}
-					// Validate the time format at each step
-					valid := false
-					if len(newTime) == 1 {
-						// First digit of hour (0-2)
-						if h, err := strconv.Atoi(newTime); err == nil && h >= 0 && h <= 2 {
-							valid = true
-						}
-					} else if len(newTime) == 3 { // After auto-adding colon (HH:)
-						// Complete hour (00-23)
-						if h, err := strconv.Atoi(newTime[:2]); err == nil && h >= 0 && h <= 23 {
-							valid = true
-						}
-					} else if len(newTime) == 4 {
-						// First digit of minute (0-5)
-						if newTime[2] == ':' {
-							if m, err := strconv.Atoi(newTime[3:]); err == nil && m >= 0 && m <= 5 {
-								valid = true
-							}
-						}
-					} else if len(newTime) == 6 { // After auto-adding colon (HH:MM:)
-						// Complete minute (00-59)
-						if newTime[2] == ':' {
-							if m, err := strconv.Atoi(newTime[3:5]); err == nil && m >= 0 && m <= 59 {
-								valid = true
-							}
-						}
-					} else if len(newTime) == 7 {
-						// First digit of second (0-5)
-						if newTime[5] == ':' {
-							if s, err := strconv.Atoi(newTime[6:]); err == nil && s >= 0 && s <= 5 {
-								valid = true
-							}
-						}
-					} else if len(newTime) == 8 {
-						// Complete second (00-59)
-						if newTime[5] == ':' {
-							if s, err := strconv.Atoi(newTime[6:]); err == nil && s >= 0 && s <= 59 {
-								valid = true
-							}
-						}
-					}
-					if valid {
-						currentTime = newTime
-						if app.entryEditCursor == EntryCursorStart {
-							app.entryStartTime = currentTime
-						} else {
-							app.entryEndTime = currentTime
-						}
-					}
-				}
+			app.enterTimeField(cursor)
+		} else if app.pressed("timefield.prev_field") {
+			if app.entryEditCursor != EntryCursorStart {
+				app.commitTimeField()
+				app.enterTimeField(app.entryEditCursor - 1)
 			}
+		} else {
+			app.handleTimeFieldKeys(key, isTimer)
 		}
 	} else if app.entryEditCursor == EntryCursorTask {
-		if key.Matches('/') {
+		if app.pressed("editor.search") {
 			app.taskSearchMode = true
 			app.taskSearchInput = ""
 			return false
-		} else if key.Matches('j') || key.Matches(vaxis.KeyDown) {
+		} else if app.pressed("tasks.down") {
 			if app.selectedTask < app.drawnTasks-1 {
 				app.selectedTask++
 			}
-		} else if key.Matches('k') || key.Matches(vaxis.KeyUp) {
+		} else if app.pressed("tasks.up") {
 			if app.selectedTask > 0 {
 				app.selectedTask--
 			}
-		} else if key.Matches('g') {
+		} else if app.pressed("tasks.page_down") {
+			app.selectedTask = min(app.selectedTask+app.taskList.page(), app.drawnTasks-1)
+		} else if app.pressed("tasks.page_up") {
+			app.selectedTask = max(0, app.selectedTask-app.taskList.page())
+		} else if app.pressed("tasks.first") {
 			app.selectedTask = 0
-		} else if key.Matches('G') {
+		} else if app.pressed("tasks.last") {
 			app.selectedTask = app.drawnTasks - 1
+		} else if app.pressed("tasks.archived") {
+			selectedID := app.highlightedTaskID()
+			app.showArchivedTasks = !app.showArchivedTasks
+			app.taskHierarchy = app.buildTaskHierarchy()
+			app.selectedTask = max(0, app.findTaskIndex(strconv.Itoa(selectedID)))
+		} else if app.pressed("tasks.metadata") {
+			app.showTaskMetadata = !app.showTaskMetadata
+		} else if app.pressed("tasks.detail") {
+			app.showTaskDetail = app.highlightedTaskID() != 0
+		} else {
+			app.startTaskAction()
 		}
 	}
 
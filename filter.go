@@ -0,0 +1,183 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SortAPI = iota
+	SortStart
+	SortDuration
+	SortTask
+	SortBillable
+)
+
+var sortNames = []string{"default", "start", "duration", "task", "billable"}
+
+func (app *App) entryMatchesFilter(entry EntryResponse) bool {
+	if app.tagFilter != "" && !entryHasTag(entry, app.tagFilter) {
+		return false
+	}
+	if app.textFilter != "" && !strings.Contains(strings.ToLower(entry.Description), strings.ToLower(app.textFilter)) {
+		return false
+	}
+	if app.billableFilter && entry.Billable == 0 {
+		return false
+	}
+	if app.timerFilter && !app.isEntryTimer(entry) {
+		return false
+	}
+	if app.taskFilter != 0 && !app.isInTaskSubtree(entry.TaskID, app.taskFilter) {
+		return false
+	}
+	return true
+}
+
+func (app *App) isFiltered() bool {
+	return app.tagFilter != "" || app.textFilter != "" || app.billableFilter || app.timerFilter || app.taskFilter != 0
+}
+
+func (app *App) clearFilters() {
+	app.tagFilter = ""
+	app.textFilter = ""
+	app.billableFilter = false
+	app.timerFilter = false
+	app.taskFilter = 0
+}
+
+// isInTaskSubtree reports whether the task is rootID or one of its descendants
+func (app *App) isInTaskSubtree(taskID string, rootID int) bool {
+	id, err := strconv.Atoi(taskID)
+	for err == nil && id != 0 {
+		if id == rootID {
+			return true
+		}
+		task := findTask(app.tasks, id)
+		if task == nil {
+			return false
+		}
+		id = task.ParentID
+	}
+	return false
+}
+
+func (app *App) entryElapsed(entry EntryResponse) time.Duration {
+	seconds, _ := strconv.ParseInt(entry.Duration, 10, 64)
+	if seconds == 0 && entry.StartTime == entry.EndTime {
+		givenTime, _ := time.ParseInLocation("2006-01-02 15:04:05", entry.Date+" "+entry.StartTime, app.selectedDate.Location())
+		return elapsedOnDate(givenTime).Round(time.Second)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (app *App) sortEntryIndexes(indexes []int) {
+	less := func(a, b EntryResponse) bool { return false }
+	switch app.entrySort {
+	case SortStart:
+		less = func(a, b EntryResponse) bool { return a.StartTime < b.StartTime }
+	case SortDuration:
+		less = func(a, b EntryResponse) bool { return app.entryElapsed(a) < app.entryElapsed(b) }
+	case SortTask:
+		less = func(a, b EntryResponse) bool { return strings.ToLower(a.Name) < strings.ToLower(b.Name) }
+	case SortBillable:
+		less = func(a, b EntryResponse) bool { return a.Billable > b.Billable }
+	default:
+		return
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := app.entries[indexes[i]], app.entries[indexes[j]]
+		if app.entrySortReverse {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+// visibleEntryIndexes returns the indexes into app.entries of the rows shown in
+// the entries list, in display order
+func (app *App) visibleEntryIndexes() []int {
+	indexes := make([]int, 0, len(app.entries))
+	for i, entry := range app.entries {
+		if app.entryMatchesFilter(entry) {
+			indexes = append(indexes, i)
+		}
+	}
+	app.sortEntryIndexes(indexes)
+	return indexes
+}
+
+// ensureVisibleSelection moves the selection onto a visible row when the
+// current one is hidden by a filter, or clears it when no row is visible
+func (app *App) ensureVisibleSelection() {
+	visible := app.visibleEntryIndexes()
+	if len(visible) == 0 {
+		app.selectedEntry = -1
+		return
+	}
+	for _, index := range visible {
+		if index == app.selectedEntry {
+			return
+		}
+	}
+	app.selectedEntry = visible[0]
+}
+
+// hasVisibleSelection reports whether the selected entry is a row on screen,
+// which entry actions require
+func (app *App) hasVisibleSelection() bool {
+	return app.selectedEntry >= 0 && app.selectedEntry < len(app.entries) && app.entryMatchesFilter(app.entries[app.selectedEntry])
+}
+
+func (app *App) moveSelection(delta int) {
+	visible := app.visibleEntryIndexes()
+	position := -1
+	for i, index := range visible {
+		if index == app.selectedEntry {
+			position = i
+		}
+	}
+	if position < 0 {
+		app.selectedEntry = -1
+		if len(visible) > 0 {
+			app.selectedEntry = visible[0]
+		}
+		return
+	}
+	position = max(0, min(len(visible)-1, position+delta))
+	app.selectedEntry = visible[position]
+}
+
+func (app *App) filterHeaderText() string {
+	var filters []string
+	if app.entrySort != SortAPI {
+		direction := "↑"
+		if app.entrySortReverse {
+			direction = "↓"
+		}
+		filters = append(filters, "sort:"+sortNames[app.entrySort]+direction)
+	}
+	if app.taskFilter != 0 {
+		if task := findTask(app.tasks, app.taskFilter); task != nil {
+			filters = append(filters, "task:"+task.Name)
+		}
+	}
+	if app.textFilter != "" {
+		filters = append(filters, "/"+app.textFilter)
+	}
+	if app.tagFilter != "" {
+		filters = append(filters, "#"+app.tagFilter)
+	}
+	if app.billableFilter {
+		filters = append(filters, "$")
+	}
+	if app.timerFilter {
+		filters = append(filters, "⏱")
+	}
+	if len(filters) == 0 {
+		return ""
+	}
+	return "  [" + strings.Join(filters, " ") + "]"
+}
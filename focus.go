@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+const (
+	FocusOff = iota
+	FocusWork
+	FocusBreak
+)
+
+// toggleFocus starts a focus session on the running timer, starting the timer
+// when needed, or ends the current session
+func (app *App) toggleFocus() {
+	if app.focusPhase != FocusOff {
+		app.stopFocus()
+		app.statusMessage = "Focus session ended"
+		return
+	}
+	if len(app.timers) == 0 {
+		if err := app.startTimer(); err != nil {
+			app.statusMessage = "Start timer failed: " + err.Error()
+			return
+		}
+	}
+	app.focusTaskID = app.runningTaskID()
+	app.beginFocusBlock(FocusWork)
+
+	app.focusTicker = time.NewTicker(1 * time.Second)
+	app.focusDone = make(chan struct{})
+	go func(ticker *time.Ticker, done chan struct{}) {
+		for {
+			select {
+			case now := <-ticker.C:
+				app.checkFocus(now)
+				app.vx.PostEvent(vaxis.Redraw{})
+			case <-done:
+				return
+			}
+		}
+	}(app.focusTicker, app.focusDone)
+}
+
+func (app *App) stopFocus() {
+	app.focusPhase = FocusOff
+	if app.focusTicker != nil {
+		app.focusTicker.Stop()
+		app.focusTicker = nil
+	}
+	if app.focusDone != nil {
+		close(app.focusDone)
+		app.focusDone = nil
+	}
+}
+
+func (app *App) beginFocusBlock(phase int) {
+	minutes := app.config.FocusMinutes
+	if phase == FocusBreak {
+		minutes = app.config.BreakMinutes
+	}
+	app.focusPhase = phase
+	app.focusEnds = time.Now().Add(time.Duration(minutes) * time.Minute)
+}
+
+// checkFocus ends the current block once its time is up. A finished work
+// block stops the timer or switches it to the break task, and a finished
+// break switches back to the work task or ends the session. The session ends
+// early when the timer that should be running was stopped
+func (app *App) checkFocus(now time.Time) {
+	if app.focusPhase == FocusOff || now.Before(app.focusEnds) {
+		return
+	}
+	// A timer stopped by hand ends the session instead of being restarted
+	timerExpected := app.focusPhase == FocusWork || app.config.FocusEnd == "switch" && app.config.BreakTask != 0
+	if timerExpected && len(app.timers) == 0 {
+		app.stopFocus()
+		app.statusMessage = "Focus session ended, the timer was stopped"
+		return
+	}
+	switch app.focusPhase {
+	case FocusWork:
+		day := now.Format("2006-01-02")
+		if app.focusDay != day {
+			app.focusDay = day
+			app.focusSessions = 0
+		}
+		app.focusSessions++
+		app.notifyFocus("Focus session done", fmt.Sprintf("Session %d today, take a %d minute break", app.focusSessions, app.config.BreakMinutes))
+		if app.config.FocusEnd == "switch" && app.config.BreakTask != 0 {
+			app.switchTimerTask(app.config.BreakTask)
+		} else if err := app.stopTimers(); err != nil {
+			app.statusMessage = "Stop timer failed: " + err.Error()
+		}
+		app.beginFocusBlock(FocusBreak)
+	case FocusBreak:
+		app.notifyFocus("Break over", "Time to focus again")
+		if app.config.FocusEnd == "switch" {
+			app.switchTimerTask(app.focusTaskID) // Without a task when the session started without one
+			app.beginFocusBlock(FocusWork)
+		} else {
+			app.stopFocus()
+		}
+	}
+}
+
+// switchTimerTask stops the running timer and starts one on the task, or
+// without a task when it is 0
+func (app *App) switchTimerTask(taskID int) {
+	if err := app.stopTimers(); err != nil {
+		app.statusMessage = "Stop timer failed: " + err.Error()
+		return
+	}
+	if err := app.startTimerOnTask(taskID); err != nil {
+		app.statusMessage = "Start timer failed: " + err.Error()
+	}
+}
+
+// notifyFocus rings the bell and sends a desktop notification on terminals
+// that support OSC 9 or OSC 777
+func (app *App) notifyFocus(title, body string) {
+	app.statusMessage = title
+	app.vx.Bell()
+	app.vx.Notify(title, body)
+}
+
+// focusSegments describes the focus block for the Timer panel
+func (app *App) focusSegments() []vaxis.Segment {
+	sessions := app.focusSessions
+	if app.focusDay != time.Now().Format("2006-01-02") {
+		sessions = 0
+	}
+	counter := fmt.Sprintf("  %d sessions today", sessions)
+	if sessions == 1 {
+		counter = "  1 session today"
+	}
+	if app.focusPhase == FocusOff {
+		if sessions == 0 {
+			return nil
+		}
+		return []vaxis.Segment{{Text: counter[2:], Style: app.style("muted")}}
+	}
+	label, role := "Focus", "accent"
+	if app.focusPhase == FocusBreak {
+		label, role = "Break", "success"
+	}
+	remaining := time.Until(app.focusEnds)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return []vaxis.Segment{
+		{Text: fmt.Sprintf("%s: %s left", label, formatClock(remaining)), Style: app.style(role)},
+		{Text: counter, Style: app.style("muted")},
+	}
+}
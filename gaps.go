@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+type entryGap struct {
+	Start time.Time
+	End   time.Time
+}
+
+// entryGaps returns the unaccounted time between the first start and the last
+// end of the day's entries
+func (app *App) entryGaps() []entryGap {
+	var intervals []entryGap
+	for _, entry := range app.entries {
+		start, end, ok := entryTimes(entry)
+		if !ok || end.Before(start) {
+			continue
+		}
+		intervals = append(intervals, entryGap{Start: start, End: end})
+	}
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+	var gaps []entryGap
+	for i := 1; i < len(intervals); i++ {
+		if intervals[i].Start.After(intervals[i-1].End) {
+			gaps = append(gaps, entryGap{Start: intervals[i-1].End, End: intervals[i].Start})
+		}
+		if intervals[i].End.Before(intervals[i-1].End) {
+			intervals[i].End = intervals[i-1].End // Keep the furthest end reached so far
+		}
+	}
+	return gaps
+}
+
+func (app *App) overlappingEntries() map[int64]bool {
+	overlapping := map[int64]bool{}
+	for i, a := range app.entries {
+		for _, b := range app.entries[i+1:] {
+			if entriesOverlap(a, b) {
+				overlapping[a.ID] = true
+				overlapping[b.ID] = true
+			}
+		}
+	}
+	return overlapping
+}
+
+func (app *App) gapAfter(entry EntryResponse) (entryGap, bool) {
+	_, end, ok := entryTimes(entry)
+	if !ok {
+		return entryGap{}, false
+	}
+	for _, gap := range app.entryGaps() {
+		if gap.Start.Equal(end) {
+			return gap, true
+		}
+	}
+	return entryGap{}, false
+}
+
+func gapRowSegments(gap entryGap, markColumn bool) []vaxis.Segment {
+	dim := vaxis.Style{Attribute: vaxis.AttrDim}
+	prefix := "┄ "
+	if markColumn {
+		prefix = "  " + prefix
+	}
+	return []vaxis.Segment{
+		{Text: prefix, Style: dim},
+		{Text: fmt.Sprintf("%-10s", gap.End.Sub(gap.Start).String()), Style: dim},
+		{Text: gap.Start.Format("15:04:05") + " - " + gap.End.Format("15:04:05"), Style: dim},
+		{Text: " unaccounted", Style: vaxis.Style{Attribute: vaxis.AttrDim | vaxis.AttrItalic}},
+	}
+}
+
+func (app *App) startFillGap() {
+	if !app.hasVisibleSelection() {
+		return
+	}
+	gap, ok := app.gapAfter(app.entries[app.selectedEntry])
+	if !ok {
+		app.statusMessage = "No gap after this entry"
+		return
+	}
+	app.pendingGap = gap
+	app.openTaskPicker(TaskPickFillGap, app.entries[app.selectedEntry].TaskID)
+}
+
+func (app *App) fillGap(gap entryGap, taskID int) {
+	entry := EntryResponse{
+		Date:      app.selectedDate.Format("2006-01-02"),
+		StartTime: gap.Start.Format("15:04:05"),
+		EndTime:   gap.End.Format("15:04:05"),
+		TaskID:    strconv.Itoa(taskID),
+	}
+	go func() {
+		if _, err := app.createEntry(entry); err != nil {
+			app.statusMessage = "Fill gap failed: " + err.Error()
+		}
+		app.fetchEntries(app.selectedDate)
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
@@ -2,8 +2,9 @@ module github.com/AbeEstrada/tuicamp
 
 go 1.24.2
 
+require git.sr.ht/~rockorager/vaxis v0.13.0
+
 require (
-	git.sr.ht/~rockorager/vaxis v0.13.0 // indirect
 	github.com/containerd/console v1.0.3 // indirect
 	github.com/mattn/go-runewidth v0.0.14 // indirect
 	github.com/mattn/go-sixel v0.0.5 // indirect
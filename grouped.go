@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+const (
+	GroupRowParent = iota
+	GroupRowTask
+	GroupRowEntry
+)
+
+type groupRow struct {
+	kind       int
+	key        string
+	label      string
+	color      string
+	depth      int
+	total      time.Duration
+	entry      EntryResponse
+	entryIndex int // Index into app.entries, -1 for week entries and group rows
+}
+
+type taskGroup struct {
+	key     string
+	label   string
+	color   string
+	total   time.Duration
+	entries []int
+	tasks   map[string]*taskGroup
+}
+
+// rootTask walks up the hierarchy to the top-level task of an entry
+func (app *App) rootTask(taskID string) *TaskResponse {
+	id, err := strconv.Atoi(taskID)
+	if err != nil {
+		return nil
+	}
+	task := findTask(app.tasks, id)
+	for task != nil && task.ParentID != 0 {
+		parent := findTask(app.tasks, task.ParentID)
+		if parent == nil {
+			break
+		}
+		task = parent
+	}
+	return task
+}
+
+func (app *App) groupSourceEntries() []EntryResponse {
+	if !app.groupWeek {
+		return app.entries
+	}
+	return app.rangeEntries
+}
+
+// groupRows flattens the entries into parent task, task and entry rows,
+// skipping the children of collapsed groups
+func (app *App) groupRows() []groupRow {
+	entries := app.groupSourceEntries()
+	parents := map[string]*taskGroup{}
+	for i, entry := range entries {
+		if !app.entryMatchesFilter(entry) {
+			continue
+		}
+		elapsed := app.entryElapsed(entry)
+		parentKey, parentLabel, parentColor := "none", "No task", ""
+		if root := app.rootTask(entry.TaskID); root != nil {
+			parentKey, parentLabel, parentColor = strconv.Itoa(root.TaskID), root.Name, root.Color
+		}
+		parent := parents[parentKey]
+		if parent == nil {
+			parent = &taskGroup{key: "p" + parentKey, label: parentLabel, color: parentColor, tasks: map[string]*taskGroup{}}
+			parents[parentKey] = parent
+		}
+		parent.total += elapsed
+		if entry.TaskID == parentKey || parentKey == "none" {
+			parent.entries = append(parent.entries, i)
+			continue
+		}
+		task := parent.tasks[entry.TaskID]
+		if task == nil {
+			task = &taskGroup{key: "t" + entry.TaskID, label: entry.Name, color: entry.Color}
+			parent.tasks[entry.TaskID] = task
+		}
+		task.total += elapsed
+		task.entries = append(task.entries, i)
+	}
+
+	var rows []groupRow
+	entryRows := func(indexes []int, depth int) {
+		for _, i := range indexes {
+			entryIndex := i
+			if app.groupWeek {
+				entryIndex = -1
+			}
+			rows = append(rows, groupRow{kind: GroupRowEntry, depth: depth, entry: entries[i], entryIndex: entryIndex})
+		}
+	}
+	for _, parent := range sortedGroups(parents) {
+		rows = append(rows, groupRow{kind: GroupRowParent, key: parent.key, label: parent.label, color: parent.color, total: parent.total, entryIndex: -1})
+		if app.collapsedGroups[parent.key] {
+			continue
+		}
+		entryRows(parent.entries, 1)
+		for _, task := range sortedGroups(parent.tasks) {
+			rows = append(rows, groupRow{kind: GroupRowTask, key: task.key, label: task.label, color: task.color, depth: 1, total: task.total, entryIndex: -1})
+			if !app.collapsedGroups[task.key] {
+				entryRows(task.entries, 2)
+			}
+		}
+	}
+	return rows
+}
+
+func sortedGroups(groups map[string]*taskGroup) []*taskGroup {
+	sorted := make([]*taskGroup, 0, len(groups))
+	for _, group := range groups {
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return strings.ToLower(sorted[i].label) < strings.ToLower(sorted[j].label)
+	})
+	return sorted
+}
+
+func (app *App) weekRange() (time.Time, time.Time) {
+	return weekOf(app.selectedDate)
+}
+
+// weekOf returns the Sunday to Saturday week containing the date
+func weekOf(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), date.Day()-int(date.Weekday()), 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, 0, 6)
+}
+
+func (app *App) fetchRangeEntries() error {
+	from, to := app.weekRange()
+	entries, err := app.fetchEntriesRange(from, to)
+	if err != nil {
+		return err
+	}
+	app.rangeEntries = entries
+	return nil
+}
+
+func (app *App) drawGroupedEntries(win vaxis.Window) {
+	title := app.selectedDate.Format("Monday, January 2, 2006")
+	if app.groupWeek {
+		from, to := app.weekRange()
+		title = "Week " + from.Format("Jan 2") + " - " + to.Format("Jan 2, 2006")
+	}
+	header := [][]vaxis.Segment{{{
+		Text:  title,
+		Style: app.style("title"),
+	}, {
+		Text:  "  by task" + app.filterHeaderText(),
+		Style: app.style("accent"),
+	}}, nil}
+	if app.groupWeek && app.rangeEntries == nil {
+		header = append(header, []vaxis.Segment{{
+			Text:  "Loading entries...",
+			Style: app.style("loading"),
+		}})
+		app.entriesList.draw(win, header, nil, nil, -1)
+		return
+	}
+
+	groups := app.groupRows()
+	app.groupCursor = max(0, min(app.groupCursor, len(groups)-1))
+
+	var total time.Duration
+	for _, row := range groups {
+		if row.kind == GroupRowParent {
+			total += row.total
+		}
+	}
+	var rows [][]vaxis.Segment
+	for i, row := range groups {
+		selected := vaxis.Style{}
+		if i == app.groupCursor && app.focusedWindow == WinEntries {
+			selected = app.style("selection")
+		}
+		indent := strings.Repeat("  ", row.depth)
+		switch row.kind {
+		case GroupRowParent, GroupRowTask:
+			arrow := "▾ "
+			if app.collapsedGroups[row.key] {
+				arrow = "▸ "
+			}
+			label := row.label
+			if row.kind == GroupRowParent {
+				selected.Attribute |= vaxis.AttrBold
+			}
+			rows = append(rows, []vaxis.Segment{
+				{Text: indent + arrow},
+				{Text: "● ", Style: vaxis.Style{Foreground: app.theme.hex(row.color), Attribute: vaxis.AttrBold}},
+				{Text: fmt.Sprintf("%-10s", row.total.String()), Style: selected},
+				{Text: label, Style: selected},
+			})
+		case GroupRowEntry:
+			dim := app.style("muted")
+			when := row.entry.StartTime + " - " + row.entry.EndTime
+			if app.groupWeek {
+				when = row.entry.Date + " " + when
+			}
+			rows = append(rows, []vaxis.Segment{
+				{Text: indent + "  "},
+				{Text: fmt.Sprintf("%-10s", app.entryElapsed(row.entry).String()), Style: selected},
+				{Text: when, Style: selected},
+				{Text: " " + row.entry.Description, Style: dim},
+			})
+		}
+	}
+	var footer [][]vaxis.Segment
+	if len(rows) > 0 {
+		footer = [][]vaxis.Segment{nil, {{
+			Text:  "Total " + total.String(),
+			Style: vaxis.Style{Attribute: vaxis.AttrBold},
+		}}}
+	}
+	app.entriesList.draw(win, header, rows, footer, app.groupCursor)
+	app.onListMouse(&app.entriesList, func(row int) bool {
+		app.focusedWindow = WinEntries
+		if row == app.groupCursor {
+			return app.runAction("grouped.toggle")
+		}
+		app.groupCursor = row
+		return false
+	}, func(delta int) {
+		app.groupCursor = max(0, min(app.groupCursor+delta, len(rows)-1))
+	})
+}
+
+func (app *App) toggleGroupedView() {
+	app.groupedView = !app.groupedView
+	app.groupCursor = 0
+	if !app.groupedView {
+		app.groupWeek = false
+	}
+}
+
+func (app *App) toggleGroupWeek() {
+	app.groupWeek = !app.groupWeek
+	app.groupCursor = 0
+	if app.groupWeek {
+		app.rangeEntries = nil
+		go func() {
+			if err := app.fetchRangeEntries(); err != nil {
+				app.statusMessage = "Loading week failed: " + err.Error()
+			}
+			app.vx.PostEvent(vaxis.Redraw{})
+		}()
+	}
+}
+
+// handleGroupedKeys handles the keys of the grouped layout, returning false for
+// keys that should fall through to the regular entries list handling
+func (app *App) handleGroupedKeys(key vaxis.Key) bool {
+	rows := app.groupRows()
+	var current *groupRow
+	if app.groupCursor < len(rows) {
+		current = &rows[app.groupCursor]
+	}
+	if app.pressed("grouped.down") {
+		app.groupCursor = min(app.groupCursor+1, len(rows)-1)
+	} else if app.pressed("grouped.up") {
+		app.groupCursor = max(0, app.groupCursor-1)
+	} else if app.pressed("grouped.page_down") {
+		app.groupCursor = min(app.groupCursor+app.entriesList.page(), len(rows)-1)
+	} else if app.pressed("grouped.page_up") {
+		app.groupCursor = max(0, app.groupCursor-app.entriesList.page())
+	} else if app.pressed("grouped.toggle_view") {
+		app.toggleGroupedView()
+	} else if app.pressed("grouped.week") {
+		app.toggleGroupWeek()
+	} else if current != nil && current.kind != GroupRowEntry && (app.pressed("grouped.toggle") || app.pressed("grouped.collapse") || app.pressed("grouped.expand")) {
+		if app.collapsedGroups == nil {
+			app.collapsedGroups = map[string]bool{}
+		}
+		collapse := !app.collapsedGroups[current.key]
+		if !app.pressed("grouped.toggle") {
+			collapse = app.pressed("grouped.collapse")
+		}
+		app.collapsedGroups[current.key] = collapse
+	} else if current != nil && current.kind == GroupRowEntry && app.pressed("entries.continue") {
+		app.continueEntry(current.entry) // Also works on the other days of the week range
+	} else if current != nil && current.entryIndex >= 0 && (app.pressed("grouped.toggle") || app.pressed("entries.edit") || app.pressed("entries.delete")) {
+		app.selectedEntry = current.entryIndex
+		if app.pressed("grouped.toggle") {
+			app.keyAction = "entries.edit"
+		}
+		return false
+	} else if app.pressed("entries.undo") || app.pressed("entries.redo") || app.pressed("entries.focus_calendar") || strings.HasSuffix(app.keyAction, "_filter") || app.pressed("entries.clear_filters") {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/widgets/border"
+)
+
+// openHelp shows the keys of the scopes active when help was requested, so
+// the overlay matches what the handlers will do with the next key
+func (app *App) openHelp() {
+	app.helpScopes = app.activeKeyScopes()
+	app.helpCursor = 0
+	app.helpList.offset = 0
+	app.showHelp = true
+}
+
+func (app *App) helpRows() [][]vaxis.Segment {
+	var rows [][]vaxis.Segment
+	for _, name := range app.helpScopes {
+		scope := findKeyScope(name)
+		if scope == nil {
+			continue
+		}
+		if len(rows) > 0 {
+			rows = append(rows, nil)
+		}
+		rows = append(rows, []vaxis.Segment{{
+			Text:  scope.Title,
+			Style: app.style("accent"),
+		}})
+		for _, action := range scope.Actions {
+			keys := app.keymap.keys(scope.Name, action.Name)
+			if len(keys) == 0 || !app.actionAvailable(scope.Name+"."+action.Name) {
+				continue
+			}
+			rows = append(rows, []vaxis.Segment{
+				{Text: fmt.Sprintf("  %-20s", strings.Join(keys, ", ")), Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+				{Text: action.Help},
+			})
+		}
+	}
+	return rows
+}
+
+func (app *App) drawHelp(win vaxis.Window) {
+	width, height := win.Size()
+	helpWidth := min(64, width-4)
+	helpHeight := max(3, height-4)
+	helpWin := win.New((width-helpWidth)/2, (height-helpHeight)/2, helpWidth, helpHeight)
+	helpWin.Clear()
+	helpWin = border.All(helpWin, app.style("focus"))
+	rows := app.helpRows()
+	app.helpCursor = max(0, min(app.helpCursor, len(rows)-1))
+	footer := [][]vaxis.Segment{{{
+		Text:  "Close with " + strings.Join(app.keymap.keys("help", "close"), ", "),
+		Style: app.style("muted"),
+	}}}
+	app.helpList.draw(helpWin, nil, rows, footer, app.helpCursor)
+	app.onMouse(helpWin, nil, func(delta int) {
+		if delta > 0 {
+			app.runAction("help.down")
+		} else {
+			app.runAction("help.up")
+		}
+	})
+}
+
+func (app *App) handleHelpKeys(key vaxis.Key) {
+	// The cursor is kept at the bottom or top edge so the list scrolls right away
+	if app.pressed("help.close") {
+		app.showHelp = false
+	} else if app.pressed("help.down") {
+		app.helpCursor = app.helpList.offset + app.helpList.height
+	} else if app.pressed("help.up") {
+		app.helpCursor = app.helpList.offset - 1
+	} else if app.pressed("help.page_down") {
+		app.helpCursor = app.helpList.offset + app.helpList.height + app.helpList.page() - 1
+	} else if app.pressed("help.page_up") {
+		app.helpCursor = app.helpList.offset - app.helpList.page()
+	}
+}
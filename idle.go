@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/widgets/border"
+)
+
+const idleProbeInterval = 15 * time.Second
+
+// idleProbe reports how long the whole system has been without user input, so
+// work in other windows does not count as idle
+type idleProbe interface {
+	Idle() (time.Duration, error)
+}
+
+// commandIdleProbe runs a command printing the idle time in milliseconds, such
+// as xprintidle
+type commandIdleProbe struct {
+	args []string
+}
+
+func (probe commandIdleProbe) Idle() (time.Duration, error) {
+	output, err := exec.Command(probe.args[0], probe.args[1:]...).Output()
+	if err != nil {
+		return 0, fmt.Errorf("idle command failed: %w", err)
+	}
+	milliseconds, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("idle command printed %q", strings.TrimSpace(string(output)))
+	}
+	return time.Duration(milliseconds) * time.Millisecond, nil
+}
+
+func newIdleProbe(command string) idleProbe {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	return commandIdleProbe{args: args}
+}
+
+// noteInput records user activity. It reports whether the event ended an idle
+// period, in which case it only opens the idle prompt
+func (app *App) noteInput(now time.Time) bool {
+	app.lastInput = now
+	if app.idleSince.IsZero() || app.showIdlePrompt {
+		return false
+	}
+	app.promptIdle(now)
+	return true
+}
+
+// checkIdle runs on every tick of the running timer and remembers when the
+// user went idle for longer than the configured threshold
+func (app *App) checkIdle(now time.Time) {
+	threshold := time.Duration(app.config.IdleMinutes) * time.Minute
+	if threshold <= 0 || app.showIdlePrompt {
+		return
+	}
+	idle := now.Sub(app.lastInput)
+	if app.idleProbe != nil {
+		if now.Sub(app.lastProbe) >= idleProbeInterval {
+			app.lastProbe = now
+			app.systemIdle = -1
+			if systemIdle, err := app.idleProbe.Idle(); err == nil {
+				app.systemIdle = systemIdle
+			}
+		}
+		if app.systemIdle >= 0 {
+			idle = app.systemIdle + now.Sub(app.lastProbe)
+		}
+	}
+	if app.idleSince.IsZero() {
+		if idle >= threshold {
+			app.idleSince = now.Add(-idle)
+		}
+		return
+	}
+	if idle < threshold {
+		app.promptIdle(now.Add(-idle)) // Back at work in another window
+		app.vx.PostEvent(vaxis.Redraw{})
+	}
+}
+
+func (app *App) promptIdle(until time.Time) {
+	if len(app.timers) == 0 {
+		app.idleSince = time.Time{}
+		return
+	}
+	app.idleUntil = until
+	app.showIdlePrompt = true
+}
+
+// resolveIdle ends the running entry where the idle time began and restarts
+// the timer on the same task, optionally recording the idle time as a separate
+// entry on that task. The work done between the end of the idle time and the
+// answer to the prompt is kept as an entry of its own
+func (app *App) resolveIdle(split bool) {
+	since, until := app.idleSince, app.idleUntil
+	app.showIdlePrompt = false
+	app.idleSince = time.Time{}
+	if len(app.timers) == 0 {
+		return
+	}
+	timer := app.timers[0]
+	startedAt, err := time.ParseInLocation("2006-01-02 15:04:05", timer.StartedAt, app.currentMonth.Location())
+	if err != nil {
+		app.statusMessage = "Cannot read the timer start: " + err.Error()
+		return
+	}
+	if since.Before(startedAt) {
+		since = startedAt
+	}
+	taskID := app.runningTaskID()
+	go func() {
+		app.beginJournalGroup()
+		err := app.trimIdleTime(startedAt, since, until, taskID, split)
+		app.endJournalGroup()
+		idle := until.Sub(since).Round(time.Minute)
+		switch {
+		case err != nil:
+			app.statusMessage = "Removing idle time failed: " + err.Error()
+		case split:
+			app.statusMessage = fmt.Sprintf("Moved %s of idle time to a separate entry", idle)
+		default:
+			app.statusMessage = fmt.Sprintf("Discarded %s of idle time", idle)
+		}
+		app.fetchEntries(app.selectedDate)
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
+
+// trimIdleTime ends the stopped entry where the idle time began, records the
+// idle time when splitting and the time since it ended, and restarts the timer
+// with the same description
+func (app *App) trimIdleTime(startedAt, since, until time.Time, taskID int, split bool) error {
+	stoppedAt := time.Now()
+	entryID, err := app.stopTimerEntry()
+	if err != nil {
+		return err
+	}
+	worked, ok := app.findEntryByID(entryID)
+	if !ok {
+		worked = EntryResponse{ID: entryID, TaskID: strconv.Itoa(taskID)}
+		if entries, err := app.fetchEntriesRange(startedAt, startedAt); err == nil {
+			for _, entry := range entries {
+				if entry.ID == entryID {
+					worked = entry
+				}
+			}
+		}
+	}
+	resumed := worked // Keeps the task, description and tags
+
+	worked.Date = startedAt.Format("2006-01-02")
+	worked.StartTime = startedAt.Format("15:04:05")
+	worked.EndTime = since.Format("15:04:05")
+	if since.Format("2006-01-02") != worked.Date {
+		err = app.saveOvernightEntry(worked, nil, worked.StartTime, worked.EndTime)
+	} else {
+		err = app.putEntry(EntryUpdate{
+			ID:        worked.ID,
+			Date:      worked.Date,
+			StartTime: worked.StartTime,
+			EndTime:   worked.EndTime,
+			Duration:  entryDuration(worked),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	if split {
+		idle := EntryResponse{TaskID: strconv.Itoa(taskID), Description: "Idle"}
+		if err := app.createEntrySpan(idle, since, until); err != nil {
+			return err
+		}
+	}
+	if stoppedAt.Sub(until) >= time.Second {
+		if err := app.createEntrySpan(resumed, until, stoppedAt); err != nil {
+			return err
+		}
+	}
+
+	newID, err := app.startTimerEntry(taskID)
+	if err == nil && newID != 0 && worked.Description != "" {
+		err = app.sendEntryUpdate(EntryUpdate{ID: newID, Description: &worked.Description})
+	}
+	return err
+}
+
+// createEntrySpan records the entry from one time to another, continuing it
+// on the next day when it runs past midnight
+func (app *App) createEntrySpan(entry EntryResponse, from, to time.Time) error {
+	entry.Date = from.Format("2006-01-02")
+	entry.StartTime = from.Format("15:04:05")
+	entry.EndTime = to.Format("15:04:05")
+	overnight := to.Format("2006-01-02") != entry.Date
+	if overnight {
+		entry.EndTime = dayEndTime
+	}
+	var err error
+	entry.ID, err = app.createEntry(entry)
+	if err != nil || !overnight {
+		return err
+	}
+	return app.saveOvernightEntry(entry, nil, entry.StartTime, to.Format("15:04:05"))
+}
+
+func (app *App) drawIdleDialog(win vaxis.Window) {
+	width, height := win.Size()
+	dialogWidth := min(56, width)
+	dialogHeight := 5
+	dialogWin := win.New((width-dialogWidth)/2, (height-dialogHeight)/2, dialogWidth, dialogHeight)
+	dialogWin.Clear()
+	dialogWin = border.All(dialogWin, app.style("warning"))
+	idle := app.idleUntil.Sub(app.idleSince).Round(time.Minute)
+	dialogWin.Println(0, vaxis.Segment{
+		Text:  fmt.Sprintf("Idle for %s since %s", idle, app.idleSince.Format("15:04")),
+		Style: vaxis.Style{Attribute: vaxis.AttrBold},
+	})
+	dialogWin.Println(1, vaxis.Segment{Text: "The timer kept running. What about that time?"})
+	buttons := []struct {
+		label  string
+		action string
+	}{{" Keep ", "idle.keep"}, {" Discard ", "idle.discard"}, {" Split ", "idle.split"}}
+	col := 0
+	for _, button := range buttons {
+		buttonWin := dialogWin.New(col, 2, len(button.label), 1)
+		buttonWin.Print(vaxis.Segment{Text: button.label, Style: app.style("selection")})
+		action := button.action
+		app.onMouse(buttonWin, func(col, row int) bool {
+			return app.runAction(action)
+		}, nil)
+		col += len(button.label) + 2
+	}
+}
+
+func (app *App) handleIdleKeys(key vaxis.Key) {
+	if app.pressed("idle.keep") {
+		app.showIdlePrompt = false
+		app.idleSince = time.Time{}
+	} else if app.pressed("idle.discard") {
+		app.resolveIdle(false)
+	} else if app.pressed("idle.split") {
+		app.resolveIdle(true)
+	}
+}
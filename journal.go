@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+const (
+	JournalCreate = iota
+	JournalUpdate
+	JournalDelete
+	JournalTags
+)
+
+type journalOp struct {
+	kind        int
+	before      EntryResponse // State prior to an update, delete or tags change
+	after       EntryResponse // Entry created by a create
+	update      EntryUpdate   // Change applied by an update
+	tagsAdded   []string      // Tag IDs added by a tags change
+	tagsRemoved []string      // Tag IDs removed by a tags change
+}
+
+type journalGroup []journalOp
+
+func (app *App) recordJournal(op journalOp) {
+	app.journalMutex.Lock()
+	defer app.journalMutex.Unlock()
+	if app.journalOpen != nil {
+		*app.journalOpen = append(*app.journalOpen, op)
+		return
+	}
+	app.undoStack = append(app.undoStack, journalGroup{op})
+	app.redoStack = nil
+}
+
+// beginJournalGroup collects every change recorded until endJournalGroup into
+// a single undo step. Nested groups join the outermost one
+func (app *App) beginJournalGroup() {
+	app.journalMutex.Lock()
+	defer app.journalMutex.Unlock()
+	app.journalDepth++
+	if app.journalDepth == 1 {
+		app.journalOpen = &journalGroup{}
+	}
+}
+
+func (app *App) endJournalGroup() {
+	app.journalMutex.Lock()
+	defer app.journalMutex.Unlock()
+	if app.journalDepth > 0 {
+		app.journalDepth--
+	}
+	if app.journalDepth > 0 {
+		return
+	}
+	if app.journalOpen != nil && len(*app.journalOpen) > 0 {
+		app.undoStack = append(app.undoStack, *app.journalOpen)
+		app.redoStack = nil
+	}
+	app.journalOpen = nil
+}
+
+// remapEntryID points every journaled operation at an entry's new ID after it
+// has been re-created through the API, including those of the group being
+// replayed, which is on neither stack at that point
+func (app *App) remapEntryID(current journalGroup, oldID, newID int64) {
+	app.journalMutex.Lock()
+	defer app.journalMutex.Unlock()
+	remap := func(groups []journalGroup) {
+		for _, group := range groups {
+			for i := range group {
+				if group[i].before.ID == oldID {
+					group[i].before.ID = newID
+				}
+				if group[i].after.ID == oldID {
+					group[i].after.ID = newID
+				}
+				if group[i].update.ID == oldID {
+					group[i].update.ID = newID
+				}
+			}
+		}
+	}
+	remap(app.undoStack)
+	remap(app.redoStack)
+	remap([]journalGroup{current})
+}
+
+func entryUpdateFrom(entry EntryResponse) EntryUpdate {
+	update := EntryUpdate{
+		ID:          entry.ID,
+		Date:        entry.Date,
+		StartTime:   entry.StartTime,
+		EndTime:     entry.EndTime,
+		Duration:    entryDuration(entry),
+		Billable:    &entry.Billable,
+		Description: &entry.Description,
+	}
+	if taskID, err := strconv.Atoi(entry.TaskID); err == nil {
+		update.TaskID = &taskID
+	}
+	return update
+}
+
+func (app *App) revertOp(group journalGroup, index int) error {
+	op := group[index]
+	switch op.kind {
+	case JournalCreate:
+		return app.sendDeleteEntry(op.after.ID)
+	case JournalUpdate:
+		return app.sendEntryUpdate(entryUpdateFrom(op.before))
+	case JournalDelete:
+		newID, err := app.sendCreateEntry(op.before)
+		if newID != 0 {
+			app.remapEntryID(group, op.before.ID, newID)
+		}
+		if err != nil {
+			return err
+		}
+	case JournalTags:
+		return app.changeTags(op.before.ID, op.tagsRemoved, op.tagsAdded)
+	}
+	return nil
+}
+
+func (app *App) replayOp(group journalGroup, index int) error {
+	op := group[index]
+	switch op.kind {
+	case JournalCreate:
+		newID, err := app.sendCreateEntry(op.after)
+		if newID != 0 {
+			app.remapEntryID(group, op.after.ID, newID)
+		}
+		if err != nil {
+			return err
+		}
+	case JournalUpdate:
+		return app.sendEntryUpdate(op.update)
+	case JournalDelete:
+		return app.sendDeleteEntry(op.before.ID)
+	case JournalTags:
+		return app.changeTags(op.before.ID, op.tagsAdded, op.tagsRemoved)
+	}
+	return nil
+}
+
+// changeTags adds and removes tags of an entry without journaling them
+func (app *App) changeTags(entryID int64, added, removed []string) error {
+	if len(added) > 0 {
+		if err := app.changeEntryTags(entryID, "POST", added); err != nil {
+			return err
+		}
+	}
+	if len(removed) > 0 {
+		return app.changeEntryTags(entryID, "DELETE", removed)
+	}
+	return nil
+}
+
+func (app *App) undo() {
+	app.journalMutex.Lock()
+	if len(app.undoStack) == 0 || app.journalReplaying {
+		app.journalMutex.Unlock()
+		app.statusMessage = "Nothing to undo"
+		return
+	}
+	group := app.undoStack[len(app.undoStack)-1]
+	app.undoStack = app.undoStack[:len(app.undoStack)-1]
+	app.journalReplaying = true
+	app.journalMutex.Unlock()
+
+	go func() {
+		var err error
+		pending := len(group) // Operations from here on have been reverted
+		for pending > 0 {
+			if err = app.revertOp(group, pending-1); err != nil {
+				break
+			}
+			pending--
+		}
+		app.journalMutex.Lock()
+		app.journalReplaying = false
+		// A partial undo leaves the rest to retry and the reverted part to redo
+		if pending > 0 {
+			app.undoStack = append(app.undoStack, group[:pending])
+		}
+		if pending < len(group) {
+			app.redoStack = append(app.redoStack, group[pending:])
+		}
+		app.journalMutex.Unlock()
+		if err != nil && pending < len(group) {
+			app.statusMessage = fmt.Sprintf("Undo stopped after %d of %d change(s): %v", len(group)-pending, len(group), err)
+		} else if err != nil {
+			app.statusMessage = "Undo failed: " + err.Error()
+		} else {
+			app.statusMessage = fmt.Sprintf("Undid %d change(s)", len(group))
+		}
+		app.fetchEntries(app.selectedDate)
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
+
+func (app *App) redo() {
+	app.journalMutex.Lock()
+	if len(app.redoStack) == 0 || app.journalReplaying {
+		app.journalMutex.Unlock()
+		app.statusMessage = "Nothing to redo"
+		return
+	}
+	group := app.redoStack[len(app.redoStack)-1]
+	app.redoStack = app.redoStack[:len(app.redoStack)-1]
+	app.journalReplaying = true
+	app.journalMutex.Unlock()
+
+	go func() {
+		var err error
+		done := 0 // Operations before this one have been replayed
+		for done < len(group) {
+			if err = app.replayOp(group, done); err != nil {
+				break
+			}
+			done++
+		}
+		app.journalMutex.Lock()
+		app.journalReplaying = false
+		// A partial redo leaves the replayed part to undo and the rest to retry
+		if done > 0 {
+			app.undoStack = append(app.undoStack, group[:done])
+		}
+		if done < len(group) {
+			app.redoStack = append(app.redoStack, group[done:])
+		}
+		app.journalMutex.Unlock()
+		if err != nil && done > 0 {
+			app.statusMessage = fmt.Sprintf("Redo stopped after %d of %d change(s): %v", done, len(group), err)
+		} else if err != nil {
+			app.statusMessage = "Redo failed: " + err.Error()
+		} else {
+			app.statusMessage = fmt.Sprintf("Redid %d change(s)", len(group))
+		}
+		app.fetchEntries(app.selectedDate)
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
@@ -0,0 +1,374 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// keyAction is a named command of a key scope with its default bindings. A
+// binding is a sequence of keys separated by spaces, each key optionally
+// prefixed with modifiers, e.g. "g g" or "Ctrl+x Ctrl+s"
+type keyAction struct {
+	Name string
+	Help string
+	Keys []string
+}
+
+type keyScope struct {
+	Name    string
+	Title   string
+	Actions []keyAction
+}
+
+var keyScopes = []keyScope{
+	{Name: "global", Title: "Global", Actions: []keyAction{
+		{"quit", "Quit", []string{"q"}},
+		{"next_panel", "Focus next panel", []string{"Tab"}},
+		{"help", "Show keys", []string{"?"}},
+		{"palette", "Open command palette", []string{":", "Ctrl+p"}},
+	}},
+	{Name: "palette", Title: "Command palette", Actions: []keyAction{
+		{"close", "Close palette", []string{"Esc"}},
+		{"run", "Run command", []string{"Enter"}},
+		{"down", "Next command", []string{"Down", "Ctrl+n"}},
+		{"up", "Previous command", []string{"Up", "Ctrl+p"}},
+		{"backspace", "Delete last character", []string{"Backspace"}},
+	}},
+	{Name: "help", Title: "Help", Actions: []keyAction{
+		{"close", "Close help", []string{"?", "Esc", "q"}},
+		{"down", "Scroll down", []string{"j", "Down"}},
+		{"up", "Scroll up", []string{"k", "Up"}},
+		{"page_down", "Page down", []string{"PgDn", "Ctrl+d", "Space"}},
+		{"page_up", "Page up", []string{"PgUp", "Ctrl+u"}},
+	}},
+	{Name: "idle", Title: "Idle time", Actions: []keyAction{
+		{"keep", "Keep the idle time in the entry", []string{"k", "Esc"}},
+		{"discard", "Discard the idle time", []string{"d"}},
+		{"split", "Move the idle time to a separate entry", []string{"s"}},
+	}},
+	{Name: "dialog", Title: "Confirmation", Actions: []keyAction{
+		{"confirm", "Confirm", []string{"y", "Enter"}},
+		{"cancel", "Cancel", []string{"n", "Esc", "q"}},
+	}},
+	{Name: "input", Title: "Text input", Actions: []keyAction{
+		{"submit", "Accept input", []string{"Enter"}},
+		{"cancel", "Cancel input", []string{"Esc"}},
+		{"backspace", "Delete last character", []string{"Backspace"}},
+		{"help", "Show keys", []string{"F1"}},
+	}},
+	{Name: "calendar", Title: "Calendar", Actions: []keyAction{
+		{"focus_timer", "Focus timer", []string{"L"}},
+		{"focus_entries", "Focus entries", []string{"J"}},
+		{"left", "Previous day", []string{"h", "Left"}},
+		{"right", "Next day", []string{"l", "Right"}},
+		{"up", "Previous week", []string{"k", "Up"}},
+		{"down", "Next week", []string{"j", "Down"}},
+		{"first_day", "First day of month", []string{"g", "Home"}},
+		{"last_day", "Last day of month", []string{"G", "End"}},
+		{"prev_month", "Previous month", []string{"p", "PgUp"}},
+		{"next_month", "Next month", []string{"n", "PgDn"}},
+		{"today", "Go to today", []string{"t"}},
+		{"select", "Select day (or copy entries to it)", []string{"Enter", "Space"}},
+		{"cancel_copy", "Cancel copying entries", []string{"Esc"}},
+	}},
+	{Name: "timer", Title: "Timer", Actions: []keyAction{
+		{"focus_calendar", "Focus calendar", []string{"H"}},
+		{"focus_entries", "Focus entries", []string{"J"}},
+		{"toggle", "Start or stop timer", []string{"Enter", "Space"}},
+		{"focus", "Start or end a focus session", []string{"f"}},
+	}},
+	{Name: "grouped", Title: "Grouped entries", Actions: []keyAction{
+		{"down", "Next row", []string{"j", "Down"}},
+		{"up", "Previous row", []string{"k", "Up"}},
+		{"page_down", "Page down", []string{"PgDn", "Ctrl+d"}},
+		{"page_up", "Page up", []string{"PgUp", "Ctrl+u"}},
+		{"toggle_view", "Back to the entries list", []string{"v"}},
+		{"week", "Toggle day / week range", []string{"W"}},
+		{"toggle", "Collapse or expand group (edit entry)", []string{"Enter", "Space"}},
+		{"collapse", "Collapse group", []string{"h"}},
+		{"expand", "Expand group", []string{"l"}},
+	}},
+	{Name: "entries", Title: "Entries", Actions: []keyAction{
+		{"focus_calendar", "Focus calendar", []string{"K"}},
+		{"down", "Next entry", []string{"j", "Down"}},
+		{"up", "Previous entry", []string{"k", "Up"}},
+		{"page_down", "Page down", []string{"PgDn", "Ctrl+d"}},
+		{"page_up", "Page up", []string{"PgUp", "Ctrl+u"}},
+		{"edit", "Edit entry", []string{"e", "Enter"}},
+		{"continue", "Continue entry in a new timer", []string{"r"}},
+		{"delete", "Delete entries", []string{"d"}},
+		{"mark", "Mark or unmark entry", []string{"Space"}},
+		{"visual", "Start or end range selection", []string{"V"}},
+		{"clear_marks", "Clear marks", []string{"Esc"}},
+		{"reassign", "Reassign task", []string{"t"}},
+		{"billable", "Toggle billable", []string{"b"}},
+		{"shift", "Shift times", []string{"s"}},
+		{"move", "Move to another date", []string{"m"}},
+		{"split", "Split entry", []string{"S"}},
+		{"merge", "Merge adjacent entries", []string{"M"}},
+		{"fill_gap", "Fill gap after entry", []string{"f"}},
+		{"copy", "Copy entries to another day", []string{"c"}},
+		{"copy_day", "Copy whole day to another day", []string{"C"}},
+		{"undo", "Undo", []string{"u"}},
+		{"redo", "Redo", []string{"Ctrl+r"}},
+		{"tag_filter", "Filter by tag", []string{"#"}},
+		{"text_filter", "Filter by description", []string{"/"}},
+		{"billable_filter", "Show only billable entries", []string{"$"}},
+		{"timer_filter", "Show only the running timer", []string{"R"}},
+		{"task_filter", "Filter by task and subtasks", []string{"T"}},
+		{"clear_filters", "Clear all filters", []string{"F"}},
+		{"sort", "Cycle sort order", []string{"o"}},
+		{"reverse_sort", "Reverse sort order", []string{"O"}},
+		{"toggle_view", "Group entries by task", []string{"v"}},
+	}},
+	{Name: "editor", Title: "Entry editor", Actions: []keyAction{
+		{"save", "Save entry (pick task)", []string{"Enter", "Space"}},
+		{"cancel", "Cancel editing", []string{"Esc", "q"}},
+		{"next_field", "Next field", []string{"Tab"}},
+		{"search", "Search tasks", []string{"/"}},
+		{"help", "Show keys", []string{"?", "F1"}},
+	}},
+	{Name: "timefield", Title: "Time field", Actions: []keyAction{
+		{"next_field", "Next field", []string{"Down"}},
+		{"prev_field", "Previous field", []string{"Up"}},
+		{"left", "Move cursor left", []string{"Left"}},
+		{"right", "Move cursor right", []string{"Right"}},
+		{"home", "Move cursor to start", []string{"Home"}},
+		{"end", "Move cursor to end", []string{"End"}},
+		{"backspace", "Delete before cursor", []string{"Backspace"}},
+		{"delete", "Delete under cursor", []string{"Delete"}},
+		{"increase", "Add one step", []string{"+", "="}},
+		{"decrease", "Subtract one step", []string{"-"}},
+		{"step", "Cycle step size", []string{"t"}},
+		{"now", "Set to current time", []string{"n"}},
+		{"round", "Round to configured minutes", []string{"r"}},
+	}},
+	{Name: "tags", Title: "Tags", Actions: []keyAction{
+		{"down", "Next tag", []string{"j", "Down"}},
+		{"up", "Previous tag", []string{"k", "Up"}},
+		{"toggle", "Toggle tag", []string{"Space", "x"}},
+	}},
+	{Name: "tasks", Title: "Task picker", Actions: []keyAction{
+		{"down", "Next task", []string{"j", "Down"}},
+		{"up", "Previous task", []string{"k", "Up"}},
+		{"page_down", "Page down", []string{"PgDn", "Ctrl+d"}},
+		{"page_up", "Page up", []string{"PgUp", "Ctrl+u"}},
+		{"first", "First task", []string{"g", "Home"}},
+		{"last", "Last task", []string{"G", "End"}},
+		{"archived", "Show or hide archived tasks", []string{"."}},
+		{"metadata", "Show or hide task metadata", []string{"i"}},
+		{"detail", "Show budget details", []string{"b"}},
+		{"add", "Add subtask", []string{"a"}},
+		{"add_top", "Add top-level task", []string{"A"}},
+		{"rename", "Rename task", []string{"r"}},
+		{"archive", "Archive task", []string{"x"}},
+	}},
+	{Name: "detail", Title: "Task details", Actions: []keyAction{
+		{"close", "Close details", []string{"b", "q", "Esc"}},
+		{"help", "Show keys", []string{"?"}},
+	}},
+}
+
+// keyNameAliases maps friendlier key names to the names vaxis understands
+var keyNameAliases = map[string]string{
+	"esc":       "Escape",
+	"space":     "space",
+	"pgup":      "Page_Up",
+	"pgdn":      "Page_Down",
+	"pagedown":  "Page_Down",
+	"pageup":    "Page_Up",
+	"backspace": "BackSpace",
+	"del":       "Delete",
+	"return":    "Enter",
+}
+
+type keyBinding struct {
+	action  string
+	strokes []string
+}
+
+// keymap resolves keys to the actions of the active scopes, keeping the keys of
+// an unfinished sequence
+type keymap struct {
+	bindings map[string][]keyBinding
+	pending  []vaxis.Key
+}
+
+// newKeymap builds the keymap from the default bindings, replacing the
+// bindings of every action overridden in the config
+func newKeymap(overrides map[string]map[string][]string) (*keymap, error) {
+	km := &keymap{bindings: map[string][]keyBinding{}}
+	for scopeName, actions := range overrides {
+		scope := findKeyScope(scopeName)
+		if scope == nil {
+			return nil, fmt.Errorf("unknown key scope %q", scopeName)
+		}
+		for name := range actions {
+			if scope.action(name) == nil {
+				return nil, fmt.Errorf("unknown action %q in key scope %q", name, scopeName)
+			}
+		}
+	}
+	for _, scope := range keyScopes {
+		for _, action := range scope.Actions {
+			keys := action.Keys
+			if custom, ok := overrides[scope.Name][action.Name]; ok {
+				keys = custom
+			}
+			for _, key := range keys {
+				strokes := strings.Fields(key)
+				if len(strokes) == 0 {
+					return nil, fmt.Errorf("empty key for %s.%s", scope.Name, action.Name)
+				}
+				km.bindings[scope.Name] = append(km.bindings[scope.Name], keyBinding{action: action.Name, strokes: strokes})
+			}
+		}
+	}
+	return km, nil
+}
+
+func findKeyScope(name string) *keyScope {
+	for i := range keyScopes {
+		if keyScopes[i].Name == name {
+			return &keyScopes[i]
+		}
+	}
+	return nil
+}
+
+func (scope *keyScope) action(name string) *keyAction {
+	for i := range scope.Actions {
+		if scope.Actions[i].Name == name {
+			return &scope.Actions[i]
+		}
+	}
+	return nil
+}
+
+// resolve returns the "scope.action" bound to the key in the first scope that
+// has a binding for it. While the key starts or continues a longer sequence
+// it returns waiting instead
+func (km *keymap) resolve(scopes []string, key vaxis.Key) (action string, waiting bool) {
+	sequence := append(append([]vaxis.Key{}, km.pending...), key)
+	prefix := false
+	for _, scope := range scopes {
+		for _, binding := range km.bindings[scope] {
+			matched, complete := matchSequence(binding.strokes, sequence)
+			if matched && complete {
+				km.pending = nil
+				return scope + "." + binding.action, false
+			}
+			prefix = prefix || matched
+		}
+	}
+	if prefix {
+		km.pending = sequence
+		return "", true
+	}
+	km.pending = nil
+	if len(sequence) > 1 {
+		return km.resolve(scopes, key) // Start over from the key that broke the sequence
+	}
+	return "", false
+}
+
+// keys returns the keys bound to an action for display
+func (km *keymap) keys(scope, action string) []string {
+	var keys []string
+	for _, binding := range km.bindings[scope] {
+		if binding.action == action {
+			keys = append(keys, strings.Join(binding.strokes, " "))
+		}
+	}
+	return keys
+}
+
+func matchSequence(strokes []string, sequence []vaxis.Key) (matched, complete bool) {
+	if len(sequence) > len(strokes) {
+		return false, false
+	}
+	for i, key := range sequence {
+		if !matchStroke(key, strokes[i]) {
+			return false, false
+		}
+	}
+	return true, len(sequence) == len(strokes)
+}
+
+func matchStroke(key vaxis.Key, stroke string) bool {
+	if len(stroke) > 1 {
+		parts := strings.Split(stroke, "+")
+		if name, ok := keyNameAliases[strings.ToLower(parts[len(parts)-1])]; ok {
+			parts[len(parts)-1] = name
+			stroke = strings.Join(parts, "+")
+		}
+	}
+	return key.MatchString(stroke)
+}
+
+// activeKeyScopes lists the scopes whose actions apply in the current state,
+// most specific first. Text prompts only get the input scope so typed
+// characters never trigger commands
+func (app *App) activeKeyScopes() []string {
+	if app.showIdlePrompt {
+		return []string{"idle"}
+	}
+	if app.showHelp {
+		return []string{"help"}
+	}
+	if app.showQuitConfirm {
+		return []string{"dialog"}
+	}
+	if app.showPalette {
+		return []string{"palette"}
+	}
+	switch app.focusedWindow {
+	case WinCalendar:
+		return []string{"calendar", "global"}
+	case WinTimer:
+		return []string{"timer", "global"}
+	case WinEntries:
+		switch {
+		case app.showDeleteConfirm:
+			return []string{"dialog"}
+		case app.showEditEntry && (app.taskSearchMode || app.taskInputMode != TaskInputNone):
+			return []string{"input"}
+		case app.showEditEntry && app.showArchiveConfirm:
+			return []string{"dialog"}
+		case app.showEditEntry && app.showTaskDetail:
+			return []string{"detail"}
+		case app.showEditEntry && app.entryEditCursor == EntryCursorTags:
+			return []string{"tags", "editor"}
+		case app.showEditEntry && app.entryEditCursor == EntryCursorTask:
+			return []string{"tasks", "editor"}
+		case app.showEditEntry && isTimeField(app.entryEditCursor):
+			return []string{"timefield", "editor"}
+		case app.showEditEntry:
+			return []string{"editor"}
+		case app.bulkInputMode != BulkInputNone:
+			return []string{"input"}
+		case app.groupedView:
+			return []string{"grouped", "entries", "global"}
+		}
+		return []string{"entries", "global"}
+	}
+	return []string{"global"}
+}
+
+// taskManageActions need the permission to create projects
+var taskManageActions = map[string]bool{
+	"tasks.add": true, "tasks.add_top": true, "tasks.rename": true, "tasks.archive": true,
+}
+
+// actionAvailable reports whether the user may run the "scope.action", so help
+// and the palette only list what works
+func (app *App) actionAvailable(action string) bool {
+	return !taskManageActions[action] || app.canManageTasks()
+}
+
+// pressed reports whether the current key resolved to the "scope.action"
+func (app *App) pressed(action string) bool {
+	return app.keyAction == action
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+var (
+	keyG     = vaxis.Key{Keycode: 'g', Text: "g"}
+	keyJ     = vaxis.Key{Keycode: 'j', Text: "j"}
+	keyX     = vaxis.Key{Keycode: 'x', Text: "x"}
+	keyCtrlX = vaxis.Key{Keycode: 'x', Modifiers: vaxis.ModCtrl}
+	keyCtrlS = vaxis.Key{Keycode: 's', Modifiers: vaxis.ModCtrl}
+	keyEnter = vaxis.Key{Keycode: vaxis.KeyEnter}
+	keyEsc   = vaxis.Key{Keycode: vaxis.KeyEsc}
+	keyPgDn  = vaxis.Key{Keycode: vaxis.KeyPgDown}
+)
+
+func TestMatchSequence(t *testing.T) {
+	tests := []struct {
+		strokes  []string
+		sequence []vaxis.Key
+		matched  bool
+		complete bool
+	}{
+		{[]string{"g"}, []vaxis.Key{keyG}, true, true},
+		{[]string{"g"}, []vaxis.Key{keyJ}, false, false},
+		{[]string{"g", "g"}, []vaxis.Key{keyG}, true, false},
+		{[]string{"g", "g"}, []vaxis.Key{keyG, keyG}, true, true},
+		{[]string{"g", "g"}, []vaxis.Key{keyG, keyJ}, false, false},
+		{[]string{"g"}, []vaxis.Key{keyG, keyG}, false, false},
+		{[]string{"Ctrl+x", "Ctrl+s"}, []vaxis.Key{keyCtrlX, keyCtrlS}, true, true},
+		{[]string{"Ctrl+x"}, []vaxis.Key{keyX}, false, false},
+		{[]string{"x"}, []vaxis.Key{keyCtrlX}, false, false},
+		{[]string{"Enter"}, []vaxis.Key{keyEnter}, true, true},
+		{[]string{"Esc"}, []vaxis.Key{keyEsc}, true, true},
+		{[]string{"esc"}, []vaxis.Key{keyEsc}, true, true},
+		{[]string{"PgDn"}, []vaxis.Key{keyPgDn}, true, true},
+		{[]string{"pagedown"}, []vaxis.Key{keyPgDn}, true, true},
+	}
+	for _, test := range tests {
+		matched, complete := matchSequence(test.strokes, test.sequence)
+		if matched != test.matched || complete != test.complete {
+			t.Errorf("matchSequence(%v, %d keys) = %v, %v, want %v, %v",
+				test.strokes, len(test.sequence), matched, complete, test.matched, test.complete)
+		}
+	}
+}
+
+func TestKeymapResolve(t *testing.T) {
+	km, err := newKeymap(map[string]map[string][]string{
+		"calendar": {"first_day": {"g g"}, "today": {"Ctrl+x Ctrl+s"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scopes := []string{"calendar", "global"}
+	steps := []struct {
+		key     vaxis.Key
+		action  string
+		waiting bool
+	}{
+		{keyJ, "calendar.down", false},
+		{keyG, "", true},
+		{keyG, "calendar.first_day", false},
+		{keyCtrlX, "", true},
+		{keyCtrlS, "calendar.today", false},
+		{keyG, "", true},
+		{keyJ, "calendar.down", false}, // Starts over from the key that broke the sequence
+		{keyX, "", false},
+		{keyEnter, "calendar.select", false},
+	}
+	for i, step := range steps {
+		action, waiting := km.resolve(scopes, step.key)
+		if action != step.action || waiting != step.waiting {
+			t.Errorf("step %d: resolve = %q, %v, want %q, %v", i, action, waiting, step.action, step.waiting)
+		}
+	}
+
+	// The first scope with a binding wins
+	action, _ := km.resolve([]string{"timer", "global"}, keyEnter)
+	if action != "timer.toggle" {
+		t.Errorf("resolve Enter in timer = %q, want timer.toggle", action)
+	}
+	action, _ = km.resolve([]string{"timer", "global"}, vaxis.Key{Keycode: vaxis.KeyTab})
+	if action != "global.next_panel" {
+		t.Errorf("resolve Tab in timer = %q, want global.next_panel", action)
+	}
+}
+
+func TestNewKeymapErrors(t *testing.T) {
+	tests := []map[string]map[string][]string{
+		{"nope": {"down": {"j"}}},
+		{"calendar": {"nope": {"j"}}},
+		{"calendar": {"down": {" "}}},
+	}
+	for _, overrides := range tests {
+		if _, err := newKeymap(overrides); err == nil {
+			t.Errorf("newKeymap(%v) succeeded, want an error", overrides)
+		}
+	}
+}
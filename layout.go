@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+const (
+	LayoutStandard = iota // Calendar and timer side by side above the entries
+	LayoutWide            // Calendar above the timer in a column left of the entries
+	LayoutStacked         // Every panel in a single column
+)
+
+const (
+	minLayoutCols  = 30
+	minLayoutRows  = 13 // The user bar and a whole calendar
+	calendarWidth  = 22 // Seven two digit days with separators and the border
+	calendarHeight = 10
+	stackedMaxCols = 59 // Narrower terminals use the stacked layout
+	wideMinCols    = 110
+	wideMinRows    = 22
+
+	timerSpacedRows = 8 // Inner rows of the Timer panel with blank rows between its lines
+	timerPackedRows = 6 // Inner rows of the Timer panel without them
+	minEntriesRows  = 6 // Entries panel height worth showing next to other panels
+)
+
+var layoutNames = map[string]int{"standard": LayoutStandard, "wide": LayoutWide, "stacked": LayoutStacked}
+
+type rect struct {
+	x, y, width, height int
+}
+
+// layout holds the area of every panel. An empty rect hides the panel
+type layout struct {
+	mode     int
+	tooSmall bool
+	user     rect
+	calendar rect
+	timer    rect
+	entries  rect
+}
+
+// computeLayout places the panels for a terminal of the given size. The mode
+// is a layout name from the config, or "auto" to pick one from the size
+func computeLayout(cols, rows int, mode string, focused int) layout {
+	l := layout{mode: LayoutStandard}
+	if cols < minLayoutCols || rows < minLayoutRows {
+		l.tooSmall = true
+		return l
+	}
+	if forced, ok := layoutNames[mode]; ok {
+		l.mode = forced
+	} else if cols <= stackedMaxCols {
+		l.mode = LayoutStacked
+	} else if cols >= wideMinCols && rows >= wideMinRows {
+		l.mode = LayoutWide
+	}
+
+	userRows := 3
+	l.user = rect{0, 0, cols, userRows}
+	body := rows - userRows
+	// Too short for the panels side by side: show them stacked, or only the
+	// focused one
+	if l.mode == LayoutStandard && body < calendarHeight+minEntriesRows ||
+		l.mode == LayoutWide && body < calendarHeight+timerPackedRows+2 {
+		l.mode = LayoutStacked
+	}
+	switch l.mode {
+	case LayoutWide:
+		l.calendar = rect{0, userRows, calendarWidth, calendarHeight}
+		l.timer = rect{0, userRows + calendarHeight, calendarWidth, body - calendarHeight}
+		l.entries = rect{calendarWidth, userRows, cols - calendarWidth, body}
+	case LayoutStacked:
+		timerRows := timerPackedRows + 2 // The border around the packed lines
+		if body-calendarHeight-timerRows >= minEntriesRows {
+			l.calendar = rect{0, userRows, cols, calendarHeight}
+			l.timer = rect{0, userRows + calendarHeight, cols, timerRows}
+			l.entries = rect{0, userRows + calendarHeight + timerRows, cols, body - calendarHeight - timerRows}
+			break
+		}
+		// Too short for all panels: show the focused one below the user bar
+		full := rect{0, userRows, cols, body}
+		switch focused {
+		case WinTimer:
+			l.timer = full
+		case WinEntries:
+			l.entries = full
+		default:
+			l.calendar = full
+		}
+	default:
+		l.calendar = rect{0, userRows, calendarWidth, calendarHeight}
+		l.timer = rect{calendarWidth, userRows, cols - calendarWidth, calendarHeight}
+		l.entries = rect{0, userRows + calendarHeight, cols, body - calendarHeight}
+	}
+	return l
+}
+
+func (app *App) UpdateDimensions() {
+	cols, rows := app.vx.Window().Size()
+	app.layout = computeLayout(cols, rows, app.config.Layout, app.focusedWindow)
+}
+
+// drawPanel draws a bordered panel in its layout area, skipping hidden panels
+func (app *App) drawPanel(parent vaxis.Window, area rect, window int, draw func(vaxis.Window)) {
+	if area.width <= 0 || area.height <= 0 {
+		return
+	}
+	win := app.createStyledWindow(parent, area.x, area.y, area.width, area.height, app.focusedWindow == window)
+	app.focusOnClick(win, window)
+	draw(win)
+}
+
+func (app *App) drawTooSmall(win vaxis.Window) {
+	cols, rows := win.Size()
+	message := fmt.Sprintf("Terminal too small: %dx%d, need %dx%d", cols, rows, minLayoutCols, minLayoutRows)
+	win.New(0, rows/2, cols, 1).Println(0, vaxis.Segment{Text: message, Style: app.style("warning")})
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestComputeLayout(t *testing.T) {
+	tests := []struct {
+		cols, rows int
+		mode       string
+		want       int
+		single     bool // Only the focused panel is shown
+	}{
+		{80, 24, "auto", LayoutStandard, false},
+		{80, 19, "auto", LayoutStandard, false},
+		{80, 18, "auto", LayoutStacked, true},
+		{80, 13, "auto", LayoutStacked, true},
+		{120, 22, "auto", LayoutWide, false},
+		{120, 21, "auto", LayoutStandard, false},
+		{120, 20, "wide", LayoutStacked, true},
+		{120, 21, "wide", LayoutWide, false},
+		{50, 27, "auto", LayoutStacked, false},
+		{50, 26, "auto", LayoutStacked, true},
+	}
+	for _, test := range tests {
+		l := computeLayout(test.cols, test.rows, test.mode, WinEntries)
+		if l.tooSmall || l.mode != test.want {
+			t.Errorf("computeLayout(%d, %d, %q) mode = %d (too small %v), want %d", test.cols, test.rows, test.mode, l.mode, l.tooSmall, test.want)
+			continue
+		}
+		if single := l.calendar.height == 0; single != test.single {
+			t.Errorf("computeLayout(%d, %d, %q) single panel = %v, want %v", test.cols, test.rows, test.mode, single, test.single)
+		}
+		for name, area := range map[string]rect{"calendar": l.calendar, "timer": l.timer, "entries": l.entries} {
+			if area.height < 0 || area.y+area.height > test.rows {
+				t.Errorf("computeLayout(%d, %d, %q) %s = %+v, outside the terminal", test.cols, test.rows, test.mode, name, area)
+			}
+			if area.height > 0 && name == "entries" && area.height < minEntriesRows && !test.single {
+				t.Errorf("computeLayout(%d, %d, %q) entries height = %d", test.cols, test.rows, test.mode, area.height)
+			}
+		}
+	}
+	if l := computeLayout(80, 12, "auto", WinEntries); !l.tooSmall {
+		t.Errorf("computeLayout(80, 12) is not too small")
+	}
+}
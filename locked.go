@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// isEntryLocked reports whether an entry was approved or invoiced and can no
+// longer be changed
+func isEntryLocked(entry EntryResponse) bool {
+	return entry.Locked == "1" || (entry.InvoiceID != "" && entry.InvoiceID != "0")
+}
+
+func lockReason(entry EntryResponse) string {
+	if entry.InvoiceID != "" && entry.InvoiceID != "0" {
+		return fmt.Sprintf("invoiced (invoice %s)", entry.InvoiceID)
+	}
+	return "locked by approval"
+}
+
+func lockedError(entry EntryResponse) error {
+	return fmt.Errorf("entry is %s", lockReason(entry))
+}
+
+func (app *App) drawLockedBanner(win vaxis.Window, row int, entry EntryResponse) {
+	win.Println(row, vaxis.Segment{
+		Text:  "🔒 Read only: this entry is " + lockReason(entry),
+		Style: app.style("warning"),
+	})
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,26 +25,78 @@ type App struct {
 	showDeleteConfirm bool
 	showEditEntry     bool
 
-	userRows int
+	layout layout
 
 	entriesCols   int
-	entriesRows   int
-	entriesCursor int
+	entriesList   scrollList
 	selectedEntry int
 
+	markedEntries    map[int64]bool
+	visualAnchor     int
+	bulkInputMode    int
+	bulkInput        string
+	taskPickMode     int
+	pendingGap       entryGap
+	batch            *batchProgress
+	pendingCopy      []EntryResponse
+	tagFilter        string
+	textFilter       string
+	billableFilter   bool
+	timerFilter      bool
+	taskFilter       int
+	entrySort        int
+	entrySortReverse bool
+
+	groupedView     bool
+	groupWeek       bool
+	groupCursor     int
+	collapsedGroups map[string]bool
+	rangeEntries    []EntryResponse
+
+	progressEntries []EntryResponse // Current week, for the daily and weekly targets
+
+	undoStack        []journalGroup
+	redoStack        []journalGroup
+	journalOpen      *journalGroup
+	journalDepth     int
+	journalReplaying bool
+	journalMutex     sync.Mutex
+
 	selectedTask    int
 	drawnTasks      int
+	taskList        scrollList
 	taskHierarchy   *TaskHierarchy
 	taskSearchMode  bool
 	taskSearchInput string
 
+	tagOptions  []tagOption
+	entryTags   map[string]bool
+	selectedTag int
+
+	showArchivedTasks bool
+	showTaskMetadata  bool
+	showTaskDetail    bool
+
+	taskSpent        map[int]time.Duration
+	taskSpentLoading map[int]bool
+	taskSpentErrors  map[int]error
+	taskSpentQueue   []int // Budgets to fetch after the current draw
+	taskSpentMutex   sync.Mutex
+
+	taskInputMode      int
+	taskInput          string
+	taskInputParentID  int
+	showArchiveConfirm bool
+
 	entryEditCursor      int
 	entryStartTime       string
 	entryEndTime         string
 	entryTimeInitialized bool
+	entryDuration        string
+	entryDurationEdited  bool
+	timeFieldCursor      int
+	nudgeStep            int
 
-	calendarCols int
-	calendarRows int
 	currentMonth time.Time
 	cursorDay    int
 	selectedDay  int
@@ -53,8 +106,42 @@ type App struct {
 	timerTicker *time.Ticker
 	timerDone   chan struct{}
 
+	focusPhase    int
+	focusEnds     time.Time
+	focusTaskID   int    // Task the work blocks are timed on
+	focusSessions int    // Work blocks finished on focusDay
+	focusDay      string // YYYY-MM-DD
+	focusTicker   *time.Ticker
+	focusDone     chan struct{}
+
+	lastInput      time.Time
+	idleProbe      idleProbe
+	lastProbe      time.Time
+	systemIdle     time.Duration // Last probe result, negative when it failed
+	idleSince      time.Time     // Start of the idle time, zero while active
+	idleUntil      time.Time
+	showIdlePrompt bool
+
 	apiToken  string
 	apiClient *APIClient
+	config    Config
+	keymap    *keymap
+	theme     *theme
+	keyAction string // Action the current key resolved to, as scope.action
+
+	statusMessage string
+	mouseRegions  []mouseRegion
+
+	showHelp   bool
+	helpScopes []string
+	helpCursor int
+	helpList   scrollList
+
+	showPalette     bool
+	paletteArgument *paletteCommand // Command waiting for its argument
+	paletteQuery    string
+	paletteCursor   int
+	paletteList     scrollList
 
 	me      MeResponse
 	timers  []TimersRunningResponse
@@ -69,17 +156,38 @@ func main() {
 		os.Exit(1)
 	}
 
+	config, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	keys, err := newKeymap(config.Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid key bindings: %v\n", err)
+		os.Exit(1)
+	}
+
+	if _, err := newTheme(config.Theme, config.Colors, config.ColorMode, true); err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid theme: %v\n", err)
+		os.Exit(1)
+	}
+
 	vx, err := vaxis.New(vaxis.Options{})
 	if err != nil {
 		panic(err)
 	}
 	defer vx.Close()
+	theme, _ := newTheme(config.Theme, config.Colors, config.ColorMode, vx.CanRGB()) // Validated above
 
 	now := time.Now()
 	app := &App{
 		vx:              vx,
 		focusedWindow:   WinCalendar,
 		apiToken:        apiToken,
+		config:          config,
+		keymap:          keys,
+		theme:           theme,
 		currentMonth:    time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
 		cursorDay:       now.Day(),
 		selectedDay:     now.Day(),
@@ -88,6 +196,9 @@ func main() {
 		taskSearchMode:  false,
 		taskSearchInput: "",
 		selectedTask:    -1,
+		visualAnchor:    -1,
+		lastInput:       now,
+		idleProbe:       newIdleProbe(config.IdleCommand),
 	}
 
 	app.UpdateDimensions()
@@ -111,7 +222,7 @@ type fetchError struct {
 
 func (app *App) fetchInitialData() {
 	var wg sync.WaitGroup
-	errChan := make(chan fetchError, 4)
+	errChan := make(chan fetchError, 5)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -140,6 +251,13 @@ func (app *App) fetchInitialData() {
 			errChan <- fetchError{"tasks", err}
 		}
 	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := app.fetchTags(); err != nil {
+			errChan <- fetchError{"tags", err}
+		}
+	}()
 	go func() {
 		wg.Wait()
 		close(errChan)
@@ -152,22 +270,11 @@ func (app *App) fetchInitialData() {
 	}()
 }
 
-func (app *App) UpdateDimensions() {
-	_, rows := app.vx.Window().Size()
-	app.userRows = 3
-	app.calendarCols = 22
-	app.calendarRows = 10
-	app.entriesRows = rows - app.calendarRows
-}
-
 func (app *App) createStyledWindow(parent vaxis.Window, x, y, width, height int, isFocused bool) vaxis.Window {
 	win := parent.New(x, y, width, height)
 	style := vaxis.Style{}
 	if isFocused {
-		style = vaxis.Style{
-			Foreground: vaxis.IndexColor(4),
-			Attribute:  vaxis.AttrBold,
-		}
+		style = app.style("focus")
 	}
 	return border.All(win, style)
 }
@@ -175,39 +282,47 @@ func (app *App) createStyledWindow(parent vaxis.Window, x, y, width, height int,
 func (app *App) Draw() {
 	mainWin := app.vx.Window()
 	mainWin.Clear()
+	app.mouseRegions = nil
 
-	cols, _ := app.vx.Window().Size()
-
-	userWin := app.createStyledWindow(mainWin, 0, 0, cols, app.userRows, app.focusedWindow == WinUser)
-	app.drawUserWindow(userWin)
-
-	calendarWin := app.createStyledWindow(mainWin, 0, app.userRows, app.calendarCols, app.calendarRows, app.focusedWindow == WinCalendar)
-	app.drawCalendarWindow(calendarWin)
-
-	timerWin := app.createStyledWindow(mainWin, app.calendarCols, app.userRows, cols-app.calendarCols, app.calendarRows, app.focusedWindow == WinTimer)
-	app.drawTimerWindow(timerWin)
-
-	contentWin := app.createStyledWindow(mainWin, 0, app.calendarRows+app.userRows, cols, app.entriesRows, app.focusedWindow == WinEntries)
-	app.drawEntriesWindow(contentWin)
-
+	app.UpdateDimensions() // The stacked layout depends on the focused panel
+	if app.layout.tooSmall {
+		app.drawTooSmall(mainWin)
+		app.vx.Render()
+		return
+	}
+	app.drawPanel(mainWin, app.layout.user, WinUser, app.drawUserWindow)
+	app.drawPanel(mainWin, app.layout.calendar, WinCalendar, app.drawCalendarWindow)
+	app.drawPanel(mainWin, app.layout.timer, WinTimer, app.drawTimerWindow)
+	app.drawPanel(mainWin, app.layout.entries, WinEntries, app.drawEntriesWindow)
+
+	if app.showIdlePrompt {
+		app.onModal(mainWin)
+		app.drawIdleDialog(mainWin)
+	}
 	if app.showQuitConfirm {
-		app.drawConfirmationDialog(mainWin, "Quit the application?", 4)
+		app.onModal(mainWin)
+		app.drawConfirmationDialog(mainWin, "Quit the application?", "focus")
+	}
+	if app.showPalette {
+		app.onModal(mainWin)
+		app.drawPalette(mainWin)
+	}
+	if app.showHelp {
+		app.onModal(mainWin)
+		app.drawHelp(mainWin)
 	}
 
 	app.vx.Render()
 }
 
-func (app *App) drawConfirmationDialog(win vaxis.Window, message string, highlightColor uint8) {
+func (app *App) drawConfirmationDialog(win vaxis.Window, message string, role string) {
 	width, height := win.Size()
 	dialogWidth := 40
-	dialogHeight := 3
+	dialogHeight := 4
 	dialogX := (width - dialogWidth) / 2
 	dialogY := (height - dialogHeight) / 2
 	dialogWin := win.New(dialogX, dialogY, dialogWidth, dialogHeight)
-	dialogWin = border.All(dialogWin, vaxis.Style{
-		Foreground: vaxis.IndexColor(highlightColor),
-		Attribute:  vaxis.AttrBold,
-	})
+	dialogWin = border.All(dialogWin, app.style(role))
 	dialogWin.Print(
 		vaxis.Segment{
 			Text: message,
@@ -216,12 +331,39 @@ func (app *App) drawConfirmationDialog(win vaxis.Window, message string, highlig
 			},
 		},
 	)
+	buttons := []struct {
+		label  string
+		action string
+	}{{" Yes ", "dialog.confirm"}, {" No ", "dialog.cancel"}}
+	col := 0
+	for _, button := range buttons {
+		buttonWin := dialogWin.New(col, 1, len(button.label), 1)
+		buttonWin.Print(vaxis.Segment{Text: button.label, Style: app.style("selection")})
+		action := button.action
+		app.onMouse(buttonWin, func(col, row int) bool {
+			return app.runAction(action)
+		}, nil)
+		col += len(button.label) + 2
+	}
 }
 
 func (app *App) HandleEvent(ev vaxis.Event) bool {
 	switch ev := ev.(type) {
 	case vaxis.Key:
+		if app.noteInput(time.Now()) {
+			return false
+		}
 		return app.HandleKeyEvent(ev)
+	case vaxis.Mouse:
+		// Pointer motion is reported too, which should neither clear messages
+		// nor count as activity. Wheel events are presses
+		if ev.EventType == vaxis.EventPress {
+			app.statusMessage = ""
+			if app.noteInput(time.Now()) {
+				return false
+			}
+		}
+		return app.HandleMouseEvent(ev)
 	case vaxis.Resize:
 		app.UpdateDimensions()
 	}
@@ -229,6 +371,36 @@ func (app *App) HandleEvent(ev vaxis.Event) bool {
 }
 
 func (app *App) HandleKeyEvent(key vaxis.Key) bool {
+	app.statusMessage = ""
+	if key.Matches('c', vaxis.ModCtrl) {
+		return true // Exit application
+	}
+	action, waiting := app.keymap.resolve(app.activeKeyScopes(), key)
+	if waiting {
+		return false
+	}
+	app.keyAction = action
+	return app.dispatchKey(key)
+}
+
+// dispatchKey runs the handlers of the focused panel for a key whose action has
+// been resolved into app.keyAction
+func (app *App) dispatchKey(key vaxis.Key) bool {
+	if app.showIdlePrompt {
+		app.handleIdleKeys(key)
+		return false
+	}
+	if app.showHelp {
+		app.handleHelpKeys(key)
+		return false
+	}
+	if app.showPalette && !app.showQuitConfirm {
+		return app.handlePaletteKeys(key)
+	}
+	if strings.HasSuffix(app.keyAction, ".help") {
+		app.openHelp()
+		return false
+	}
 	if app.handleGlobalKeys(key) {
 		return true
 	}
@@ -245,24 +417,19 @@ func (app *App) HandleKeyEvent(key vaxis.Key) bool {
 
 func (app *App) handleGlobalKeys(key vaxis.Key) bool {
 	if app.showQuitConfirm {
-		if key.Matches('y') || key.Matches(vaxis.KeyEnter) {
+		if app.pressed("dialog.confirm") {
 			return true // Confirm quit
-		} else if key.Matches('n') || key.Matches(vaxis.KeyEsc) || key.Matches('q') {
+		} else if app.pressed("dialog.cancel") {
 			app.showQuitConfirm = false
 		}
 		return false
-	} else if !app.showDeleteConfirm && !app.showEditEntry {
-		if key.Matches('q') {
-			app.showQuitConfirm = true
-			return false
-		}
-		if key.Matches(vaxis.KeyTab) {
-			app.focusedWindow = (app.focusedWindow % 3) + 1
-		}
-
 	}
-	if key.Matches('c', vaxis.ModCtrl) {
-		return true // Exit application
+	if app.pressed("global.quit") {
+		app.showQuitConfirm = true
+	} else if app.pressed("global.palette") {
+		app.openPalette()
+	} else if app.pressed("global.next_panel") {
+		app.focusedWindow = (app.focusedWindow % 3) + 1
 	}
 	return false
 }
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// mouseRegion is an area of the screen registered while drawing. Click gets
+// the position relative to the region and reports whether to quit
+type mouseRegion struct {
+	col, row      int
+	width, height int
+	click         func(col, row int) bool
+	scroll        func(delta int)
+	modal         bool // Swallows events for the regions below
+}
+
+// onMouse registers handlers for the area of win. Regions registered later are
+// drawn on top and get events first
+func (app *App) onMouse(win vaxis.Window, click func(col, row int) bool, scroll func(delta int)) {
+	col, row := win.Origin()
+	width, height := win.Size()
+	app.mouseRegions = append(app.mouseRegions, mouseRegion{
+		col: col, row: row, width: width, height: height,
+		click: click, scroll: scroll,
+	})
+}
+
+// onModal blocks mouse events from reaching anything below an overlay
+func (app *App) onModal(win vaxis.Window) {
+	col, row := win.Origin()
+	width, height := win.Size()
+	app.mouseRegions = append(app.mouseRegions, mouseRegion{col: col, row: row, width: width, height: height, modal: true})
+}
+
+// onListMouse makes the rows of a scroll list clickable by index
+func (app *App) onListMouse(list *scrollList, click func(index int) bool, scroll func(delta int)) {
+	app.onMouse(list.body, func(col, row int) bool {
+		index := list.offset + row
+		if index >= list.count {
+			return false
+		}
+		return click(index)
+	}, scroll)
+}
+
+func (app *App) HandleMouseEvent(mouse vaxis.Mouse) bool {
+	if mouse.EventType != vaxis.EventPress {
+		return false
+	}
+	delta := 0
+	switch mouse.Button {
+	case vaxis.MouseWheelUp:
+		delta = -1
+	case vaxis.MouseWheelDown:
+		delta = 1
+	case vaxis.MouseLeftButton:
+	default:
+		return false
+	}
+	for i := len(app.mouseRegions) - 1; i >= 0; i-- {
+		region := app.mouseRegions[i]
+		if mouse.Col < region.col || mouse.Col >= region.col+region.width ||
+			mouse.Row < region.row || mouse.Row >= region.row+region.height {
+			continue
+		}
+		if delta == 0 && region.click != nil {
+			return region.click(mouse.Col-region.col, mouse.Row-region.row)
+		}
+		if delta != 0 && region.scroll != nil {
+			region.scroll(delta)
+			return false
+		}
+		if region.modal {
+			return false
+		}
+	}
+	return false
+}
+
+// runAction performs an action as if its key had been pressed
+func (app *App) runAction(action string) bool {
+	app.keyAction = action
+	return app.dispatchKey(vaxis.Key{})
+}
+
+// focusOnClick makes a click anywhere in a panel focus it
+func (app *App) focusOnClick(win vaxis.Window, window int) {
+	app.onMouse(win, func(col, row int) bool {
+		if app.canChangeFocus() {
+			app.focusedWindow = window
+		}
+		return false
+	}, nil)
+}
+
+// canChangeFocus reports whether the entries panel can lose focus, which it
+// cannot while a dialog, the editor or a prompt is open
+func (app *App) canChangeFocus() bool {
+	return !app.showEditEntry && !app.showDeleteConfirm && app.bulkInputMode == BulkInputNone
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// dayEndTime is where entries cut at midnight end, as TimeCamp times cannot
+// reach 24:00. Durations are computed up to it so they agree with the times
+const dayEndTime = "23:59:59"
+
+// isOvernight reports whether an end time before the start time means the
+// entry runs past midnight
+func isOvernight(startTime, endTime string) bool {
+	start, err1 := parseTimeInput(startTime)
+	end, err2 := parseTimeInput(endTime)
+	return err1 == nil && err2 == nil && end.Before(start)
+}
+
+// saveOvernightEntry ends the entry at midnight and records the remainder as a
+// new entry on the following day, unless the entry ends exactly at midnight
+func (app *App) saveOvernightEntry(entry EntryResponse, taskID *int, startTime, endTime string) error {
+	start, err := parseTimeInput(startTime)
+	if err != nil {
+		return err
+	}
+	date, err := time.ParseInLocation("2006-01-02", entry.Date, app.selectedDate.Location())
+	if err != nil {
+		date = app.selectedDate
+	}
+	dayEnd, _ := parseTimeInput(dayEndTime)
+	nextDay := date.AddDate(0, 0, 1)
+
+	app.beginJournalGroup()
+	defer app.endJournalGroup()
+	err = app.putEntry(EntryUpdate{
+		ID:        entry.ID,
+		Date:      date.Format("2006-01-02"),
+		StartTime: start.Format("15:04:05"),
+		EndTime:   dayEndTime,
+		Duration:  int(dayEnd.Sub(start).Seconds()),
+		TaskID:    taskID,
+	})
+	if err != nil {
+		return err
+	}
+	if end, err := parseTimeInput(endTime); err == nil && end.Format("15:04:05") == "00:00:00" {
+		return nil // Nothing left for the next day
+	}
+	second := entry
+	second.Date = nextDay.Format("2006-01-02")
+	second.StartTime = "00:00:00"
+	second.EndTime = endTime
+	if taskID != nil {
+		second.TaskID = fmt.Sprint(*taskID)
+	}
+	if _, err := app.createEntry(second); err != nil {
+		return err
+	}
+	app.statusMessage = "Entry continues on " + nextDay.Format("Monday, January 2")
+	return nil
+}
+
+// elapsedOnDate returns how much of a running timer falls on the day it started
+func elapsedOnDate(startedAt time.Time) time.Duration {
+	dayEnd := time.Date(startedAt.Year(), startedAt.Month(), startedAt.Day()+1, 0, 0, 0, 0, startedAt.Location())
+	end := time.Now()
+	if end.After(dayEnd) {
+		end = dayEnd
+	}
+	return end.Sub(startedAt)
+}
+
+// carriedTimer returns the part of a timer started on an earlier day that falls
+// on the selected date
+func (app *App) carriedTimer() (time.Time, time.Duration, bool) {
+	if len(app.timers) == 0 {
+		return time.Time{}, 0, false
+	}
+	loc := app.selectedDate.Location()
+	startedAt, err := time.ParseInLocation("2006-01-02 15:04:05", app.timers[0].StartedAt, loc)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	dayStart := time.Date(app.selectedDate.Year(), app.selectedDate.Month(), app.selectedDate.Day(), 0, 0, 0, 0, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	now := time.Now()
+	if !startedAt.Before(dayStart) || !now.After(dayStart) {
+		return time.Time{}, 0, false
+	}
+	if now.After(dayEnd) {
+		now = dayEnd
+	}
+	return startedAt, now.Sub(dayStart).Round(time.Second), true
+}
+
+func carriedTimerSegments(startedAt time.Time, elapsed time.Duration, markColumn bool) []vaxis.Segment {
+	prefix := "⏱ "
+	if markColumn {
+		prefix = "  " + prefix
+	}
+	return []vaxis.Segment{
+		{Text: prefix, Style: vaxis.Style{Attribute: vaxis.AttrBold}},
+		{Text: fmt.Sprintf("%-10s", elapsed.String())},
+		{Text: "00:00:00 - ⏱       "},
+		{
+			Text:  " running since " + startedAt.Format("Mon Jan 2 15:04"),
+			Style: vaxis.Style{Attribute: vaxis.AttrItalic},
+		},
+	}
+}
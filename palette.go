@@ -0,0 +1,399 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"git.sr.ht/~rockorager/vaxis/widgets/border"
+)
+
+// paletteCommand is an entry of the command palette. Commands with a run
+// function ask for an argument first, picked from the options when there are
+// any or typed freely otherwise
+type paletteCommand struct {
+	title   string
+	keys    string // Bound keys, for display
+	window  int    // Panel focused before running the action
+	action  string // "scope.action" run for commands without an argument
+	prompt  string
+	options func() []paletteOption
+	run     func(value string)
+}
+
+type paletteOption struct {
+	label string
+	value string
+}
+
+type paletteItem struct {
+	label  string
+	detail string
+	score  int
+}
+
+// paletteSkipActions are movements that make no sense outside their panel
+var paletteSkipActions = map[string]bool{
+	"down": true, "up": true, "left": true, "right": true,
+	"page_down": true, "page_up": true, "palette": true,
+}
+
+var paletteScopes = []struct {
+	name   string
+	window int
+}{
+	{"global", WinCalendar},
+	{"calendar", WinCalendar},
+	{"timer", WinTimer},
+	{"grouped", WinEntries},
+	{"entries", WinEntries},
+}
+
+func (app *App) openPalette() {
+	app.showPalette = true
+	app.paletteArgument = nil
+	app.paletteQuery = ""
+	app.paletteCursor = 0
+	app.paletteList.offset = 0
+}
+
+// openPaletteArgument keeps the palette open to ask for the argument of a command
+func (app *App) openPaletteArgument(command *paletteCommand) {
+	app.openPalette()
+	app.paletteArgument = command
+}
+
+// paletteCommands lists the actions of the panels followed by the commands
+// that take an argument
+func (app *App) paletteCommands() []*paletteCommand {
+	var commands []*paletteCommand
+	for _, scope := range paletteScopes {
+		if scope.name == "grouped" && !app.groupedView {
+			continue
+		}
+		keyScope := findKeyScope(scope.name)
+		for _, action := range keyScope.Actions {
+			if paletteSkipActions[action.Name] || !app.actionAvailable(scope.name+"."+action.Name) {
+				continue
+			}
+			window := scope.window
+			if scope.name == "global" {
+				window = app.focusedWindow // Help lists the keys of the current panel
+			}
+			commands = append(commands, &paletteCommand{
+				title:  keyScope.Title + ": " + action.Help,
+				keys:   strings.Join(app.keymap.keys(scope.name, action.Name), ", "),
+				window: window,
+				action: scope.name + "." + action.Name,
+			})
+		}
+	}
+	return append(commands,
+		&paletteCommand{title: "Timer: Start timer on task", prompt: "Task", options: app.paletteTaskOptions, run: app.paletteStartTimer},
+		&paletteCommand{title: "Calendar: Go to date", prompt: "Date (YYYY-MM-DD, MM-DD, ±days, today)", run: app.paletteGoToDate},
+		&paletteCommand{title: "Entries: Add entry", prompt: "Time (HH:MM-HH:MM)", run: app.paletteAddEntry},
+		&paletteCommand{title: "Entries: Filter by task", prompt: "Task", options: app.paletteTaskOptions, run: app.paletteFilterTask},
+		&paletteCommand{title: "Entries: Filter by tag", prompt: "Tag", options: app.paletteTagOptions, run: app.paletteBulkInput(BulkInputTagFilter)},
+		&paletteCommand{title: "Entries: Filter by description", prompt: "Description", run: app.paletteBulkInput(BulkInputTextFilter)},
+		&paletteCommand{title: "Entries: Shift times by", prompt: "Offset (+15m, -1h)", run: app.paletteBulkInput(BulkInputShift)},
+		&paletteCommand{title: "Entries: Move to date", prompt: "Date (YYYY-MM-DD, MM-DD, ±days)", run: app.paletteBulkInput(BulkInputMove)},
+		&paletteCommand{title: "Entries: Split entry at", prompt: "Time (HH:MM, 930)", run: app.paletteBulkInput(BulkInputSplit)},
+	)
+}
+
+// paletteItems returns the commands, or the options of the command waiting for
+// an argument, that match the query, best matches first
+func (app *App) paletteItems() ([]paletteItem, []*paletteCommand, []paletteOption) {
+	var items []paletteItem
+	var commands []*paletteCommand
+	var options []paletteOption
+	if app.paletteArgument != nil {
+		if app.paletteArgument.options == nil {
+			return nil, nil, nil
+		}
+		for _, option := range app.paletteArgument.options() {
+			if score, ok := fuzzyScore(app.paletteQuery, option.label); ok {
+				items = append(items, paletteItem{label: option.label, score: score})
+				options = append(options, option)
+			}
+		}
+	} else {
+		for _, command := range app.paletteCommands() {
+			if score, ok := fuzzyScore(app.paletteQuery, command.title); ok {
+				items = append(items, paletteItem{label: command.title, detail: command.keys, score: score})
+				commands = append(commands, command)
+			}
+		}
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return items[order[i]].score > items[order[j]].score
+	})
+	sortedItems := make([]paletteItem, len(items))
+	var sortedCommands []*paletteCommand
+	var sortedOptions []paletteOption
+	for i, index := range order {
+		sortedItems[i] = items[index]
+		if commands != nil {
+			sortedCommands = append(sortedCommands, commands[index])
+		} else {
+			sortedOptions = append(sortedOptions, options[index])
+		}
+	}
+	return sortedItems, sortedCommands, sortedOptions
+}
+
+// fuzzyScore matches the query as a subsequence of the text, ignoring case.
+// Consecutive characters and characters at the start of words score higher
+func fuzzyScore(query, text string) (int, bool) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	lower := []rune(strings.ToLower(text))
+	score, position, streak := 0, 0, 0
+	for _, char := range query {
+		if char == ' ' {
+			streak = 0
+			continue
+		}
+		found := false
+		for ; position < len(lower); position++ {
+			if lower[position] != char {
+				streak = 0
+				continue
+			}
+			streak++
+			score += streak
+			if position == 0 || strings.ContainsRune(" :/-_", lower[position-1]) {
+				score += 3
+			}
+			position++
+			found = true
+			break
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return score, true
+}
+
+func (app *App) paletteTaskOptions() []paletteOption {
+	if app.taskHierarchy == nil {
+		app.taskHierarchy = app.buildTaskHierarchy()
+	}
+	var options []paletteOption
+	for _, id := range app.taskHierarchy.AllTasksIDs {
+		task := findTask(app.tasks, id)
+		if task == nil || task.Archived != 0 {
+			continue
+		}
+		label := task.Name
+		for parent := findTask(app.tasks, task.ParentID); parent != nil; parent = findTask(app.tasks, parent.ParentID) {
+			label = parent.Name + " / " + label
+		}
+		options = append(options, paletteOption{label: label, value: fmt.Sprint(task.TaskID)})
+	}
+	return options
+}
+
+func (app *App) paletteTagOptions() []paletteOption {
+	var options []paletteOption
+	for _, tag := range app.tagOptions {
+		options = append(options, paletteOption{label: tag.ListName + ": " + tag.Name, value: tag.Name})
+	}
+	return options
+}
+
+// paletteStartTimer stops the running timer, if any, and starts one on the task
+func (app *App) paletteStartTimer(value string) {
+	var taskID int
+	if _, err := fmt.Sscan(value, &taskID); err != nil {
+		app.statusMessage = "Unknown task: " + value
+		return
+	}
+	app.focusedWindow = WinTimer
+	if err := app.stopTimers(); err != nil {
+		app.statusMessage = "Stop timer failed: " + err.Error()
+		return
+	}
+	if err := app.startTimerOnTask(taskID); err != nil {
+		app.statusMessage = "Start timer failed: " + err.Error()
+	}
+}
+
+func (app *App) paletteGoToDate(value string) {
+	var date time.Time
+	var err error
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "today":
+		date = time.Now()
+	case "yesterday":
+		date = time.Now().AddDate(0, 0, -1)
+	default:
+		date, err = app.parseTargetDate(value)
+	}
+	if err != nil {
+		app.statusMessage = "Invalid date: " + err.Error()
+		return
+	}
+	app.focusedWindow = WinCalendar
+	app.currentMonth = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	app.cursorDay = date.Day()
+	app.runAction("calendar.select")
+}
+
+// paletteAddEntry reads the times of a new entry on the selected day, then
+// asks for its task
+func (app *App) paletteAddEntry(value string) {
+	startText, endText, ok := strings.Cut(value, "-")
+	start, startErr := parseTimeInput(startText)
+	end, endErr := parseTimeInput(endText)
+	if !ok || startErr != nil || endErr != nil || !end.After(start) {
+		app.statusMessage = "Invalid time range: " + value
+		return
+	}
+	day := app.selectedDate
+	at := func(t time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, day.Location())
+	}
+	gap := entryGap{Start: at(start), End: at(end)}
+	app.openPaletteArgument(&paletteCommand{
+		title:   "Entries: Add entry",
+		prompt:  fmt.Sprintf("Task for %s - %s", gap.Start.Format("15:04"), gap.End.Format("15:04")),
+		options: app.paletteTaskOptions,
+		run: func(value string) {
+			var taskID int
+			if _, err := fmt.Sscan(value, &taskID); err != nil {
+				app.statusMessage = "Unknown task: " + value
+				return
+			}
+			app.focusedWindow = WinEntries
+			app.fillGap(gap, taskID)
+		},
+	})
+}
+
+func (app *App) paletteFilterTask(value string) {
+	var taskID int
+	if _, err := fmt.Sscan(value, &taskID); err != nil {
+		app.statusMessage = "Unknown task: " + value
+		return
+	}
+	app.focusedWindow = WinEntries
+	app.taskFilter = taskID
+}
+
+// paletteBulkInput submits the value to one of the entries panel prompts, so
+// the command behaves exactly like the prompt of its key
+func (app *App) paletteBulkInput(mode int) func(value string) {
+	return func(value string) {
+		app.focusedWindow = WinEntries
+		if mode == BulkInputSplit && !app.hasVisibleSelection() {
+			return
+		}
+		app.bulkInputMode = mode
+		app.bulkInput = value
+		app.runAction("input.submit")
+	}
+}
+
+// runPaletteItem closes the palette and runs the command or argument at index
+func (app *App) runPaletteItem(index int) bool {
+	_, commands, options := app.paletteItems()
+	argument := app.paletteArgument
+	query := app.paletteQuery
+	app.showPalette = false
+	app.paletteArgument = nil
+	if argument != nil {
+		if index >= 0 && index < len(options) {
+			argument.run(options[index].value)
+		} else if argument.options == nil && strings.TrimSpace(query) != "" {
+			argument.run(query)
+		}
+		return false
+	}
+	if index < 0 || index >= len(commands) {
+		return false
+	}
+	command := commands[index]
+	if command.run != nil {
+		app.openPaletteArgument(command)
+		return false
+	}
+	app.focusedWindow = command.window
+	return app.runAction(command.action)
+}
+
+func (app *App) drawPalette(win vaxis.Window) {
+	width, height := win.Size()
+	paletteWidth := min(72, width-4)
+	paletteHeight := max(4, min(18, height-4))
+	paletteWin := win.New((width-paletteWidth)/2, 2, paletteWidth, paletteHeight)
+	paletteWin.Clear()
+	paletteWin = border.All(paletteWin, app.style("focus"))
+
+	prompt := ":"
+	if app.paletteArgument != nil {
+		prompt = app.paletteArgument.prompt + ": "
+	}
+	header := [][]vaxis.Segment{{
+		{Text: prompt, Style: app.style("prompt")},
+		{Text: app.paletteQuery},
+		{Text: " ", Style: app.style("selection")},
+	}}
+	items, _, _ := app.paletteItems()
+	app.paletteCursor = max(0, min(app.paletteCursor, len(items)-1))
+	innerWidth, _ := paletteWin.Size()
+	rows := make([][]vaxis.Segment, len(items))
+	for i, item := range items {
+		style := vaxis.Style{}
+		if i == app.paletteCursor {
+			style = app.style("selection")
+		}
+		label := item.label
+		padding := max(1, innerWidth-2-len([]rune(label))-len([]rune(item.detail)))
+		rows[i] = []vaxis.Segment{
+			{Text: label + strings.Repeat(" ", padding), Style: style},
+			{Text: item.detail, Style: app.style("muted")},
+		}
+	}
+	var footer [][]vaxis.Segment
+	if len(items) == 0 && app.paletteArgument != nil && app.paletteArgument.options == nil {
+		footer = [][]vaxis.Segment{{{Text: "Enter to run", Style: app.style("muted")}}}
+	} else if len(items) == 0 {
+		footer = [][]vaxis.Segment{{{Text: "No matches", Style: app.style("muted")}}}
+	}
+	app.paletteList.draw(paletteWin, header, rows, footer, app.paletteCursor)
+	app.onListMouse(&app.paletteList, func(index int) bool {
+		return app.runPaletteItem(index)
+	}, func(delta int) {
+		app.paletteCursor = max(0, min(app.paletteCursor+delta, len(items)-1))
+	})
+}
+
+func (app *App) handlePaletteKeys(key vaxis.Key) bool {
+	if app.pressed("palette.close") {
+		app.showPalette = false
+		app.paletteArgument = nil
+	} else if app.pressed("palette.run") {
+		return app.runPaletteItem(app.paletteCursor)
+	} else if app.pressed("palette.down") {
+		app.paletteCursor++
+	} else if app.pressed("palette.up") {
+		app.paletteCursor = max(0, app.paletteCursor-1)
+	} else if app.pressed("palette.backspace") {
+		if len(app.paletteQuery) > 0 {
+			runes := []rune(app.paletteQuery)
+			app.paletteQuery = string(runes[:len(runes)-1])
+			app.paletteCursor = 0
+		}
+	} else if key.Text != "" {
+		app.paletteQuery += key.Text
+		app.paletteCursor = 0
+	}
+	return false
+}
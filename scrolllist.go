@@ -0,0 +1,78 @@
+package main
+
+import (
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// scrollList is a vertically scrolling list of rows between a sticky header and
+// footer, with a scrollbar in the last column when the rows do not fit
+type scrollList struct {
+	offset int          // Index of the first visible row
+	height int          // Rows available for the list at the last draw
+	count  int          // Rows in the list at the last draw
+	body   vaxis.Window // Area of the rows at the last draw, for mouse events
+}
+
+// draw renders the header at the top, the rows scrolled so that the cursor row
+// stays in view, and the footer below the rows or pinned to the bottom
+func (list *scrollList) draw(win vaxis.Window, header, rows, footer [][]vaxis.Segment, cursor int) {
+	width, height := win.Size()
+	for i, segments := range header {
+		win.Println(i, segments...)
+	}
+	list.height = max(1, height-len(header)-len(footer))
+	list.count = len(rows)
+	list.scrollTo(cursor, len(rows))
+
+	bodyWidth := width
+	if len(rows) > list.height {
+		bodyWidth--
+		list.drawScrollbar(win.New(width-1, len(header), 1, list.height), len(rows))
+	}
+	list.body = win.New(0, len(header), bodyWidth, list.height)
+	visible := calculateVisibleEntries(rows, list.offset, list.height)
+	for i, segments := range visible {
+		list.body.Println(i, segments...)
+	}
+	top := len(header) + len(visible)
+	for i, segments := range footer {
+		win.Println(top+i, segments...)
+	}
+}
+
+// scrollTo adjusts the offset so that the cursor row is visible
+func (list *scrollList) scrollTo(cursor, count int) {
+	if cursor >= 0 {
+		if cursor < list.offset {
+			list.offset = cursor
+		}
+		if cursor >= list.offset+list.height {
+			list.offset = cursor - list.height + 1
+		}
+	}
+	list.offset = max(0, min(list.offset, count-list.height))
+}
+
+func (list *scrollList) drawScrollbar(win vaxis.Window, count int) {
+	thumbSize := max(1, list.height*list.height/count)
+	thumbTop := 0
+	if count > list.height {
+		thumbTop = list.offset * (list.height - thumbSize) / (count - list.height)
+	}
+	for row := 0; row < list.height; row++ {
+		cell := vaxis.Cell{
+			Character: vaxis.Character{Grapheme: "│", Width: 1},
+			Style:     vaxis.Style{Attribute: vaxis.AttrDim},
+		}
+		if row >= thumbTop && row < thumbTop+thumbSize {
+			cell.Character.Grapheme = "┃"
+			cell.Style = vaxis.Style{}
+		}
+		win.SetCell(0, row, cell)
+	}
+}
+
+// page returns the number of rows to move for page up and page down
+func (list *scrollList) page() int {
+	return max(1, list.height-1)
+}
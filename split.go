@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// splitEntry cuts an entry in two at the given time of day and opens the editor
+// on the second half so it can be moved to a different task
+func (app *App) splitEntry(entry EntryResponse, at string) {
+	if app.isEntryTimer(entry) {
+		app.statusMessage = "Stop the timer before splitting"
+		return
+	}
+	if isEntryLocked(entry) {
+		app.statusMessage = "Cannot split: " + lockedError(entry).Error()
+		return
+	}
+	splitAt, err := parseTimeInput(at)
+	if err != nil {
+		app.statusMessage = "Invalid split time: " + at
+		return
+	}
+	start, end, ok := entryTimes(entry)
+	if !ok || !splitAt.After(start) || !splitAt.Before(end) {
+		app.statusMessage = fmt.Sprintf("Split time must be between %s and %s", entry.StartTime, entry.EndTime)
+		return
+	}
+	go func() {
+		app.beginJournalGroup()
+		err := app.putEntry(EntryUpdate{
+			ID:        entry.ID,
+			Date:      entry.Date,
+			StartTime: entry.StartTime,
+			EndTime:   splitAt.Format("15:04:05"),
+			Duration:  int(splitAt.Sub(start).Seconds()),
+		})
+		var newID int64
+		if err == nil {
+			second := entry
+			second.StartTime = splitAt.Format("15:04:05")
+			newID, err = app.createEntry(second)
+		}
+		app.endJournalGroup()
+		if err != nil {
+			app.statusMessage = "Split failed: " + err.Error()
+			app.fetchEntries(app.selectedDate)
+			app.vx.PostEvent(vaxis.Redraw{})
+			return
+		}
+		app.fetchEntries(app.selectedDate)
+		for i, e := range app.entries {
+			if e.ID == newID {
+				app.selectedEntry = i
+				app.showEditEntry = true
+				app.entryEditCursor = EntryCursorTask
+				app.entryTimeInitialized = false
+				app.selectedTask = -1
+			}
+		}
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
+
+// mergeEntries joins back-to-back entries on the same task into the earliest one
+func (app *App) mergeEntries(entries []EntryResponse) {
+	if len(entries) == 1 {
+		// Without marks, merge the selected entry with the one that follows it
+		for _, next := range app.entries {
+			if next.Date == entries[0].Date && next.StartTime == entries[0].EndTime && next.ID != entries[0].ID && next.TaskID == entries[0].TaskID {
+				entries = append(entries, next)
+				break
+			}
+		}
+	}
+	if len(entries) < 2 {
+		app.statusMessage = "No adjacent entry on the same task to merge"
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].StartTime < entries[j].StartTime
+	})
+	descriptions := []string{}
+	for i, entry := range entries {
+		if app.isEntryTimer(entry) {
+			app.statusMessage = "Stop the timer before merging"
+			return
+		}
+		if isEntryLocked(entry) {
+			app.statusMessage = "Cannot merge: " + lockedError(entry).Error()
+			return
+		}
+		if entry.TaskID != entries[0].TaskID || entry.Date != entries[0].Date {
+			app.statusMessage = "Only entries on the same task and day can be merged"
+			return
+		}
+		if i > 0 && entry.StartTime != entries[i-1].EndTime {
+			app.statusMessage = fmt.Sprintf("Entries are not adjacent at %s", entries[i-1].EndTime)
+			return
+		}
+		if entry.Description != "" && !slices.Contains(descriptions, entry.Description) {
+			descriptions = append(descriptions, entry.Description)
+		}
+	}
+	first := entries[0]
+	first.EndTime = entries[len(entries)-1].EndTime
+	description := strings.Join(descriptions, "; ")
+	app.clearMarks()
+	go func() {
+		app.beginJournalGroup()
+		err := app.putEntry(EntryUpdate{
+			ID:          first.ID,
+			Date:        first.Date,
+			StartTime:   first.StartTime,
+			EndTime:     first.EndTime,
+			Duration:    entryDuration(first),
+			Description: &description,
+		})
+		for _, entry := range entries[1:] {
+			if err != nil {
+				break
+			}
+			err = app.deleteEntry(entry.ID)
+		}
+		app.endJournalGroup()
+		if err != nil {
+			app.statusMessage = "Merge failed: " + err.Error()
+		} else {
+			app.statusMessage = fmt.Sprintf("Merged %d entries", len(entries))
+		}
+		app.fetchEntries(app.selectedDate)
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
+}
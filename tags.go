@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+type EntryTag struct {
+	TagID       json.Number `json:"tagId"`
+	Name        string      `json:"name"`
+	TagListID   json.Number `json:"tagListId"`
+	TagListName string      `json:"tagListName"`
+}
+
+type TagListResponse struct {
+	ID       json.Number            `json:"id"`
+	Name     string                 `json:"name"`
+	Archived int                    `json:"archived"`
+	Tags     map[string]TagResponse `json:"tags"`
+}
+
+type TagResponse struct {
+	ID       json.Number `json:"id"`
+	Name     string      `json:"name"`
+	Archived int         `json:"archived"`
+}
+
+// tagOption is a tag flattened out of its list for the editor picker
+type tagOption struct {
+	ID       string
+	Name     string
+	ListName string
+}
+
+func (app *App) fetchTags() error {
+	var response map[string]TagListResponse
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint: "/tag_list?tags=true",
+		Method:   "GET",
+		Response: &response,
+		Headers:  map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return fmt.Errorf("failed API response: %w", result.Error)
+	}
+	var options []tagOption
+	for _, list := range response {
+		if list.Archived > 0 {
+			continue
+		}
+		for _, tag := range list.Tags {
+			if tag.Archived > 0 {
+				continue
+			}
+			options = append(options, tagOption{ID: tag.ID.String(), Name: tag.Name, ListName: list.Name})
+		}
+	}
+	sort.Slice(options, func(i, j int) bool {
+		if options[i].ListName != options[j].ListName {
+			return strings.ToLower(options[i].ListName) < strings.ToLower(options[j].ListName)
+		}
+		return strings.ToLower(options[i].Name) < strings.ToLower(options[j].Name)
+	})
+	app.tagOptions = options
+	return nil
+}
+
+func (app *App) changeEntryTags(entryID int64, method string, tagIDs []string) error {
+	type Body struct {
+		Tags string `json:"tags"`
+	}
+	body := Body{
+		Tags: strings.Join(tagIDs, ","),
+	}
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint:    fmt.Sprintf("/entries/%d/tags", entryID),
+		Method:      method,
+		RequestBody: &body,
+		Headers:     map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return fmt.Errorf("failed API response: %w", result.Error)
+	}
+	return nil
+}
+
+// saveEntryTags applies the difference between the entry's tags and the ones
+// chosen in the editor
+func (app *App) saveEntryTags(entry EntryResponse, selected map[string]bool) error {
+	current := map[string]bool{}
+	for _, tag := range entry.Tags {
+		current[tag.TagID.String()] = true
+	}
+	var added, removed []string
+	for id := range selected {
+		if !current[id] {
+			added = append(added, id)
+		}
+	}
+	for id := range current {
+		if !selected[id] {
+			removed = append(removed, id)
+		}
+	}
+	if len(added) > 0 {
+		if err := app.changeEntryTags(entry.ID, "POST", added); err != nil {
+			return err
+		}
+	}
+	var err error
+	if len(removed) > 0 {
+		if err = app.changeEntryTags(entry.ID, "DELETE", removed); err != nil {
+			removed = nil // Only the added tags need undoing
+		}
+	}
+	if len(added) > 0 || len(removed) > 0 {
+		app.recordJournal(journalOp{kind: JournalTags, before: entry, tagsAdded: added, tagsRemoved: removed})
+	}
+	return err
+}
+
+func entryHasTag(entry EntryResponse, name string) bool {
+	name = strings.ToLower(name)
+	return slices.ContainsFunc(entry.Tags, func(tag EntryTag) bool {
+		return strings.Contains(strings.ToLower(tag.Name), name)
+	})
+}
+
+func (app *App) tagSegments(tags []EntryTag) []vaxis.Segment {
+	segments := make([]vaxis.Segment, 0, len(tags))
+	for _, tag := range tags {
+		segments = append(segments, vaxis.Segment{
+			Text:  " #" + tag.Name,
+			Style: app.style("accent"),
+		})
+	}
+	return segments
+}
+
+func (app *App) initEntryTags(entry EntryResponse) {
+	app.entryTags = map[string]bool{}
+	for _, tag := range entry.Tags {
+		app.entryTags[tag.TagID.String()] = true
+	}
+	app.selectedTag = 0
+}
+
+func (app *App) entryTagNames() string {
+	var names []string
+	for _, option := range app.tagOptions {
+		if app.entryTags[option.ID] {
+			names = append(names, option.Name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func (app *App) drawTagPicker(win vaxis.Window, top int) {
+	if app.tagOptions == nil {
+		win.Println(top+1, vaxis.Segment{
+			Text:  "No tags available",
+			Style: app.style("loading"),
+		})
+		return
+	}
+	_, rows := win.Size()
+	visibleRows := max(1, rows-top-2)
+	scrollOffset := max(0, min(app.selectedTag-visibleRows/2, len(app.tagOptions)-visibleRows))
+	row := top + 1
+	listName := ""
+	for i := scrollOffset; i < len(app.tagOptions) && row < rows; i++ {
+		option := app.tagOptions[i]
+		if option.ListName != listName {
+			listName = option.ListName
+			win.Println(row, vaxis.Segment{Text: listName, Style: vaxis.Style{Attribute: vaxis.AttrBold}})
+			row++
+		}
+		check := "[ ] "
+		if app.entryTags[option.ID] {
+			check = "[x] "
+		}
+		style := vaxis.Style{}
+		if i == app.selectedTag {
+			style = app.style("selection")
+		}
+		win.Println(row, vaxis.Segment{Text: "  " + check}, vaxis.Segment{Text: option.Name, Style: style})
+		row++
+	}
+}
+
+func (app *App) handleTagPickerKeys(key vaxis.Key) bool {
+	if app.pressed("tags.down") {
+		app.selectedTag = min(app.selectedTag+1, len(app.tagOptions)-1)
+	} else if app.pressed("tags.up") {
+		app.selectedTag = max(0, app.selectedTag-1)
+	} else if app.pressed("tags.toggle") {
+		if app.selectedTag < len(app.tagOptions) {
+			id := app.tagOptions[app.selectedTag].ID
+			if app.entryTags[id] {
+				delete(app.entryTags, id)
+			} else {
+				app.entryTags[id] = true
+			}
+		}
+	} else {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+type targetProgress struct {
+	today       time.Duration
+	week        time.Duration
+	dailyTarget time.Duration
+	weekTarget  time.Duration
+	finish      time.Time // Zero unless the running timer reaches the daily target
+}
+
+func (app *App) hasTargets() bool {
+	return app.config.DailyTargetHours > 0 || app.config.WeeklyTargetHours > 0
+}
+
+// fetchProgressEntries loads the entries of the current week, which the
+// selected day does not necessarily belong to
+func (app *App) fetchProgressEntries() {
+	from, to := weekOf(time.Now())
+	entries, err := app.fetchEntriesRange(from, to)
+	if err != nil {
+		return
+	}
+	app.progressEntries = entries
+	app.vx.PostEvent(vaxis.Redraw{})
+}
+
+// targetProgress sums the entries of today and this week, counting the running
+// timer through the elapsed time
+func (app *App) targetProgress() targetProgress {
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	progress := targetProgress{
+		dailyTarget: time.Duration(app.config.DailyTargetHours * float64(time.Hour)),
+		weekTarget:  time.Duration(app.config.WeeklyTargetHours * float64(time.Hour)),
+	}
+	for _, entry := range app.progressEntries {
+		if app.isEntryTimer(entry) {
+			continue
+		}
+		elapsed := app.entryElapsed(entry)
+		progress.week += elapsed
+		if entry.Date == today {
+			progress.today += elapsed
+		}
+	}
+	if len(app.timers) > 0 {
+		progress.week += app.elapsedTime
+		progress.today += app.elapsedTime
+		if progress.dailyTarget > progress.today {
+			progress.finish = now.Add(progress.dailyTarget - progress.today)
+		}
+	}
+	return progress
+}
+
+// targetRole picks the style of a total against its target
+func targetRole(done, target time.Duration) string {
+	if done >= target {
+		return "success"
+	}
+	return "prompt"
+}
+
+// timerTargetSegments shows today's progress in the Timer panel
+func (app *App) timerTargetSegments() []vaxis.Segment {
+	if !app.hasTargets() || app.progressEntries == nil {
+		return nil
+	}
+	progress := app.targetProgress()
+	if progress.dailyTarget <= 0 {
+		return []vaxis.Segment{{Text: "Week " + formatHours(progress.week) + " / " + formatHours(progress.weekTarget), Style: app.style(targetRole(progress.week, progress.weekTarget))}}
+	}
+	role := targetRole(progress.today, progress.dailyTarget)
+	segments := []vaxis.Segment{
+		{Text: fmt.Sprintf("Today %s / %s ", formatHours(progress.today), formatHours(progress.dailyTarget)), Style: app.style(role)},
+		{Text: progressBar(float64(progress.today)/float64(progress.dailyTarget), 8), Style: app.style(role)},
+	}
+	switch {
+	case progress.today >= progress.dailyTarget:
+		segments = append(segments, vaxis.Segment{Text: " done", Style: app.style("success")})
+	case !progress.finish.IsZero():
+		segments = append(segments, vaxis.Segment{
+			Text:  fmt.Sprintf(" %s left, done at %s", formatHours(progress.dailyTarget-progress.today), progress.finish.Format("15:04")),
+			Style: app.style("muted"),
+		})
+	default:
+		segments = append(segments, vaxis.Segment{Text: " " + formatHours(progress.dailyTarget-progress.today) + " left", Style: app.style("muted")})
+	}
+	return segments
+}
+
+// userTargetText summarizes the day and week for the user bar
+func (app *App) userTargetText() (string, string) {
+	if !app.hasTargets() || app.progressEntries == nil {
+		return "", ""
+	}
+	progress := app.targetProgress()
+	text := ""
+	role := "muted"
+	if progress.dailyTarget > 0 {
+		text = fmt.Sprintf("Today %s/%s", formatHours(progress.today), formatHours(progress.dailyTarget))
+		role = targetRole(progress.today, progress.dailyTarget)
+	}
+	if progress.weekTarget > 0 {
+		if text != "" {
+			text += " · "
+		}
+		text += fmt.Sprintf("Week %s/%s", formatHours(progress.week), formatHours(progress.weekTarget))
+		if progress.week > progress.weekTarget {
+			text += " +" + formatHours(progress.week-progress.weekTarget) + " overtime"
+		}
+	}
+	return text, role
+}
@@ -5,16 +5,31 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+const (
+	TaskInputNone = iota
+	TaskInputCreate
+	TaskInputRename
 )
 
 type TaskResponse struct {
-	TaskID      int    `json:"task_id"`
-	ParentID    int    `json:"parent_id"`
-	AssignedBy  int    `json:"assigned_by"`
-	Name        string `json:"name"`
-	Level       int    `json:"level"`
-	BudgetUnit  string `json:"budget_unit"`
-	RootGroupID int    `json:"root_group_id"`
+	TaskID           int    `json:"task_id"`
+	ParentID         int    `json:"parent_id"`
+	AssignedBy       int    `json:"assigned_by"`
+	Name             string `json:"name"`
+	Level            int    `json:"level"`
+	Archived         int    `json:"archived"`
+	Color            string `json:"color"`
+	Budgeted         int    `json:"budgeted"`
+	BudgetUnit       string `json:"budget_unit"`
+	Keywords         string `json:"keywords"`
+	ExternalTaskID   string `json:"external_task_id"`
+	ExternalParentID string `json:"external_parent_id"`
+	RootGroupID      int    `json:"root_group_id"`
+	AddDate          string `json:"add_date"`
 }
 
 type TaskHierarchy struct {
@@ -26,7 +41,7 @@ type TaskHierarchy struct {
 func (app *App) fetchTasks() error {
 	var response map[string]TaskResponse
 	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
-		Endpoint: fmt.Sprintf("/tasks?minimal=1"),
+		Endpoint: fmt.Sprintf("/tasks?status=all"),
 		Method:   "GET",
 		Response: &response,
 		Headers:  map[string]string{"Authorization": "Bearer " + app.apiToken},
@@ -37,6 +52,7 @@ func (app *App) fetchTasks() error {
 	}
 	app.tasks = response
 	app.taskHierarchy = nil
+	app.invalidateTaskSpent()
 	return nil
 }
 
@@ -84,6 +100,9 @@ func (app *App) buildTaskHierarchy() *TaskHierarchy {
 	var parentIDs []int
 	var allTasksIDs []int
 	for _, task := range app.tasks {
+		if task.Archived > 0 && !app.showArchivedTasks {
+			continue
+		}
 		parentTasks[task.ParentID] = append(parentTasks[task.ParentID], task)
 		if task.ParentID == 0 {
 			parentIDs = append(parentIDs, task.TaskID)
@@ -117,3 +136,237 @@ func (app *App) buildTaskHierarchy() *TaskHierarchy {
 		AllTasksIDs: allTasksIDs,
 	}
 }
+
+func (app *App) canManageTasks() bool {
+	return app.me.Permissions.CreateProjects
+}
+
+func (app *App) createTask(name string, parentID int) (int, error) {
+	type Body struct {
+		Name     string `json:"name"`
+		ParentID int    `json:"parent_id,omitempty"`
+	}
+	var response map[string]TaskResponse
+	body := Body{
+		Name:     name,
+		ParentID: parentID,
+	}
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint:    "/tasks",
+		Method:      "POST",
+		RequestBody: &body,
+		Response:    &response,
+		Headers:     map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed API response: %w", result.Error)
+	}
+	for _, task := range response {
+		return task.TaskID, nil
+	}
+	return 0, nil
+}
+
+func (app *App) renameTask(taskID int, name string) error {
+	type Body struct {
+		TaskID int    `json:"task_id"`
+		Name   string `json:"name"`
+	}
+	body := Body{
+		TaskID: taskID,
+		Name:   name,
+	}
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint:    "/tasks",
+		Method:      "PUT",
+		RequestBody: &body,
+		Headers:     map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return fmt.Errorf("failed API response: %w", result.Error)
+	}
+	return nil
+}
+
+func (app *App) archiveTask(taskID int) error {
+	type Body struct {
+		TaskID   int `json:"task_id"`
+		Archived int `json:"archived"`
+	}
+	body := Body{
+		TaskID:   taskID,
+		Archived: 1,
+	}
+	resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
+		Endpoint:    "/tasks",
+		Method:      "PUT",
+		RequestBody: &body,
+		Headers:     map[string]string{"Authorization": "Bearer " + app.apiToken},
+	})
+	result := <-resultChan
+	if result.Error != nil {
+		return fmt.Errorf("failed API response: %w", result.Error)
+	}
+	return nil
+}
+
+func (app *App) highlightedTaskID() int {
+	if app.taskHierarchy == nil || app.selectedTask < 0 || app.selectedTask >= len(app.taskHierarchy.AllTasksIDs) {
+		return 0
+	}
+	return app.taskHierarchy.AllTasksIDs[app.selectedTask]
+}
+
+func (app *App) drawTaskPrompt(win vaxis.Window, row int) bool {
+	style := app.style("prompt")
+	switch {
+	case app.showArchiveConfirm:
+		name := ""
+		if task := findTask(app.tasks, app.highlightedTaskID()); task != nil {
+			name = task.Name
+		}
+		win.Println(row, vaxis.Segment{
+			Text:  "Archive " + name + "? (y/n)",
+			Style: app.style("danger"),
+		})
+	case app.taskInputMode == TaskInputCreate:
+		label := "New task: "
+		if parent := findTask(app.tasks, app.taskInputParentID); parent != nil {
+			label = "New task under " + parent.Name + ": "
+		}
+		win.Println(row, vaxis.Segment{Text: label + app.taskInput, Style: style})
+	case app.taskInputMode == TaskInputRename:
+		win.Println(row, vaxis.Segment{Text: "Rename: " + app.taskInput, Style: style})
+	default:
+		return false
+	}
+	return true
+}
+
+func (app *App) handleTaskManageKeys(key vaxis.Key) {
+	if app.showArchiveConfirm {
+		if app.pressed("dialog.confirm") {
+			app.showArchiveConfirm = false
+			taskID := app.highlightedTaskID()
+			go func() {
+				if err := app.archiveTask(taskID); err != nil {
+					app.statusMessage = "Archive failed: " + err.Error()
+				} else if err := app.fetchTasks(); err == nil {
+					app.taskHierarchy = app.buildTaskHierarchy()
+					app.selectedTask = max(0, min(app.selectedTask, len(app.taskHierarchy.AllTasksIDs)-1))
+				}
+				app.vx.PostEvent(vaxis.Redraw{})
+			}()
+		} else if app.pressed("dialog.cancel") {
+			app.showArchiveConfirm = false
+		}
+		return
+	}
+
+	if app.pressed("input.cancel") {
+		app.taskInputMode = TaskInputNone
+		app.taskInput = ""
+	} else if app.pressed("input.backspace") {
+		if len(app.taskInput) > 0 {
+			app.taskInput = app.taskInput[:len(app.taskInput)-1]
+		}
+	} else if app.pressed("input.submit") {
+		name := strings.TrimSpace(app.taskInput)
+		mode := app.taskInputMode
+		parentID := app.taskInputParentID
+		taskID := app.highlightedTaskID()
+		app.taskInputMode = TaskInputNone
+		app.taskInput = ""
+		if name == "" {
+			return
+		}
+		go func() {
+			if mode == TaskInputCreate {
+				newID, err := app.createTask(name, parentID)
+				if err != nil {
+					app.statusMessage = "Create task failed: " + err.Error()
+				} else {
+					taskID = newID
+				}
+			} else if err := app.renameTask(taskID, name); err != nil {
+				app.statusMessage = "Rename failed: " + err.Error()
+			}
+			if err := app.fetchTasks(); err == nil {
+				index := app.findTaskIndex(strconv.Itoa(taskID))
+				if index < 0 && mode == TaskInputCreate && taskID != 0 {
+					// The picker only shows two levels, so keep the parent highlighted
+					index = app.findTaskIndex(strconv.Itoa(parentID))
+					if parent := findTask(app.tasks, parentID); parent != nil {
+						app.statusMessage = fmt.Sprintf("Created %s under %s", name, parent.Name)
+					}
+				}
+				if index >= 0 {
+					app.selectedTask = index
+				}
+			}
+			app.vx.PostEvent(vaxis.Redraw{})
+		}()
+	} else if key.Text != "" {
+		app.taskInput += key.Text
+	}
+}
+
+func (app *App) startTaskAction() bool {
+	if !app.canManageTasks() {
+		return false
+	}
+	taskID := app.highlightedTaskID()
+	if app.pressed("tasks.add") {
+		app.taskInputMode = TaskInputCreate
+		app.taskInputParentID = taskID
+		app.taskInput = ""
+	} else if app.pressed("tasks.add_top") {
+		app.taskInputMode = TaskInputCreate
+		app.taskInputParentID = 0
+		app.taskInput = ""
+	} else if app.pressed("tasks.rename") && taskID != 0 {
+		app.taskInputMode = TaskInputRename
+		app.taskInput = ""
+		if task := findTask(app.tasks, taskID); task != nil {
+			app.taskInput = task.Name
+		}
+	} else if app.pressed("tasks.archive") && taskID != 0 {
+		app.showArchiveConfirm = true
+	} else {
+		return false
+	}
+	return true
+}
+
+func (app *App) taskRowSegments(task *TaskResponse, prefix string, style vaxis.Style) []vaxis.Segment {
+	if task.Archived > 0 {
+		style.Attribute |= vaxis.AttrDim
+	}
+	segments := []vaxis.Segment{
+		{Text: prefix},
+		{
+			Text: "● ",
+			Style: vaxis.Style{
+				Foreground: app.theme.hex(task.Color),
+				Attribute:  vaxis.AttrBold,
+			},
+		},
+		{Text: task.Name, Style: style},
+	}
+	dim := app.style("muted")
+	if task.Archived > 0 {
+		segments = append(segments, vaxis.Segment{Text: " (archived)", Style: dim})
+	}
+	segments = append(segments, app.taskBudgetSegments(task)...)
+	if app.showTaskMetadata {
+		if task.Keywords != "" {
+			segments = append(segments, vaxis.Segment{Text: " [" + task.Keywords + "]", Style: dim})
+		}
+		if task.ExternalTaskID != "" {
+			segments = append(segments, vaxis.Segment{Text: " #" + task.ExternalTaskID, Style: dim})
+		}
+	}
+	return segments
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+// styleSpec describes a style in the config file. Colors are palette indexes
+// ("4", "250"), ANSI names ("red", "bright-blue") or hex values ("#5f87d7")
+type styleSpec struct {
+	Fg    string   `json:"fg,omitempty"`
+	Bg    string   `json:"bg,omitempty"`
+	Attrs []string `json:"attrs,omitempty"` // bold, dim, italic, reverse, underline
+}
+
+// themeRoles lists the named styles the draw functions use
+var themeRoles = []string{
+	"focus",     // Border of the focused panel
+	"title",     // Panel titles
+	"accent",    // Filters, tags and other header information
+	"prompt",    // Text prompts and progress
+	"error",     // Invalid input and failures
+	"warning",   // Budgets close to their limit, locked entries
+	"success",   // Budgets within their limit
+	"marked",    // Marked entries
+	"weekend",   // Weekend days in the calendar
+	"current",   // Selected day and the current task of an entry
+	"selection", // Highlighted row
+	"muted",     // Secondary text
+	"loading",   // Placeholder text while loading
+	"danger",    // Destructive confirmation dialogs
+}
+
+var builtinThemes = map[string]map[string]styleSpec{
+	"dark": {
+		"focus":     {Fg: "4", Attrs: []string{"bold"}},
+		"title":     {Attrs: []string{"bold"}},
+		"accent":    {Fg: "6"},
+		"prompt":    {Fg: "3"},
+		"error":     {Fg: "1"},
+		"warning":   {Fg: "3"},
+		"success":   {Fg: "2"},
+		"marked":    {Fg: "3", Attrs: []string{"bold"}},
+		"weekend":   {Fg: "250"},
+		"current":   {Fg: "4"},
+		"selection": {Attrs: []string{"reverse"}},
+		"muted":     {Attrs: []string{"dim"}},
+		"loading":   {Attrs: []string{"italic"}},
+		"danger":    {Fg: "1", Attrs: []string{"bold"}},
+	},
+	"light": {
+		"focus":     {Fg: "#005fd7", Attrs: []string{"bold"}},
+		"title":     {Attrs: []string{"bold"}},
+		"accent":    {Fg: "#008787"},
+		"prompt":    {Fg: "#af5f00"},
+		"error":     {Fg: "#d70000"},
+		"warning":   {Fg: "#af5f00"},
+		"success":   {Fg: "#008700"},
+		"marked":    {Fg: "#af5f00", Attrs: []string{"bold"}},
+		"weekend":   {Fg: "#767676"},
+		"current":   {Fg: "#005fd7"},
+		"selection": {Attrs: []string{"reverse"}},
+		"muted":     {Fg: "#6c6c6c"},
+		"loading":   {Attrs: []string{"italic"}},
+		"danger":    {Fg: "#d70000", Attrs: []string{"bold"}},
+	},
+	"high-contrast": {
+		"focus":     {Fg: "bright-yellow", Attrs: []string{"bold"}},
+		"title":     {Fg: "bright-white", Attrs: []string{"bold", "underline"}},
+		"accent":    {Fg: "bright-cyan", Attrs: []string{"bold"}},
+		"prompt":    {Fg: "bright-yellow", Attrs: []string{"bold"}},
+		"error":     {Fg: "bright-red", Attrs: []string{"bold"}},
+		"warning":   {Fg: "bright-yellow", Attrs: []string{"bold"}},
+		"success":   {Fg: "bright-green", Attrs: []string{"bold"}},
+		"marked":    {Fg: "bright-magenta", Attrs: []string{"bold"}},
+		"weekend":   {Fg: "white"},
+		"current":   {Fg: "bright-cyan", Attrs: []string{"bold", "underline"}},
+		"selection": {Attrs: []string{"reverse", "bold"}},
+		"muted":     {Fg: "white"},
+		"loading":   {Fg: "bright-white", Attrs: []string{"italic"}},
+		"danger":    {Fg: "bright-red", Attrs: []string{"bold"}},
+	},
+}
+
+var ansiColorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// ansiColors are the xterm defaults of the 16 ANSI colors, used to find the
+// closest match on terminals without extended colors
+var ansiColors = []uint32{
+	0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd, 0xe5e5e5,
+	0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+type theme struct {
+	styles map[string]vaxis.Style
+	ansi   bool // Only the 16 ANSI colors are available
+}
+
+// newTheme resolves the named built-in theme with the user overrides applied.
+// The color mode is "truecolor", "16" or "auto", which picks 16 colors when
+// the terminal does not report RGB support
+func newTheme(name string, overrides map[string]styleSpec, colorMode string, canRGB bool) (*theme, error) {
+	specs, ok := builtinThemes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q", name)
+	}
+	t := &theme{styles: map[string]vaxis.Style{}}
+	switch colorMode {
+	case "", "auto":
+		t.ansi = !canRGB && !strings.Contains(os.Getenv("COLORTERM"), "truecolor")
+	case "16":
+		t.ansi = true
+	case "truecolor":
+	default:
+		return nil, fmt.Errorf("unknown color mode %q", colorMode)
+	}
+	for role := range overrides {
+		if !isThemeRole(role) {
+			return nil, fmt.Errorf("unknown style role %q", role)
+		}
+	}
+	for _, role := range themeRoles {
+		spec := specs[role]
+		if override, ok := overrides[role]; ok {
+			spec = override
+		}
+		style, err := t.parseSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("style %q: %w", role, err)
+		}
+		t.styles[role] = style
+	}
+	return t, nil
+}
+
+func isThemeRole(role string) bool {
+	for _, name := range themeRoles {
+		if name == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *theme) parseSpec(spec styleSpec) (vaxis.Style, error) {
+	var style vaxis.Style
+	var err error
+	if style.Foreground, err = t.parseColor(spec.Fg); err != nil {
+		return style, err
+	}
+	if style.Background, err = t.parseColor(spec.Bg); err != nil {
+		return style, err
+	}
+	for _, attr := range spec.Attrs {
+		switch attr {
+		case "bold":
+			style.Attribute |= vaxis.AttrBold
+		case "dim":
+			style.Attribute |= vaxis.AttrDim
+		case "italic":
+			style.Attribute |= vaxis.AttrItalic
+		case "reverse":
+			style.Attribute |= vaxis.AttrReverse
+		case "underline":
+			style.UnderlineStyle = vaxis.UnderlineSingle
+		default:
+			return style, fmt.Errorf("unknown attribute %q", attr)
+		}
+	}
+	return style, nil
+}
+
+func (t *theme) parseColor(color string) (vaxis.Color, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	switch {
+	case color == "" || color == "default":
+		return vaxis.Color(0), nil
+	case strings.HasPrefix(color, "#"):
+		value, err := strconv.ParseUint(color[1:], 16, 32)
+		if err != nil || len(color) != 7 {
+			return 0, fmt.Errorf("invalid color %q", color)
+		}
+		return t.rgb(uint32(value)), nil
+	}
+	if index, err := strconv.Atoi(color); err == nil {
+		if index < 0 || index > 255 {
+			return 0, fmt.Errorf("invalid color index %d", index)
+		}
+		if t.ansi && index > 15 {
+			return vaxis.IndexColor(nearestANSI(paletteRGB(index))), nil
+		}
+		return vaxis.IndexColor(uint8(index)), nil
+	}
+	name, bright := strings.CutPrefix(color, "bright-")
+	for i, ansi := range ansiColorNames {
+		if ansi == name {
+			if bright {
+				i += 8
+			}
+			return vaxis.IndexColor(uint8(i)), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid color %q", color)
+}
+
+// rgb returns a truecolor value, or the closest ANSI color in 16 color mode
+func (t *theme) rgb(value uint32) vaxis.Color {
+	if t.ansi {
+		return vaxis.IndexColor(nearestANSI(value))
+	}
+	return vaxis.HexColor(value)
+}
+
+func (t *theme) style(role string) vaxis.Style {
+	return t.styles[role]
+}
+
+// hex converts a TimeCamp color such as "#4dc4ff" for display
+func (t *theme) hex(color string) vaxis.Color {
+	value, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil {
+		return vaxis.Color(0) // Default terminal color
+	}
+	return t.rgb(uint32(value))
+}
+
+// paletteRGB returns the xterm RGB value of a 256 color palette index
+func paletteRGB(index int) uint32 {
+	if index < 16 {
+		return ansiColors[index]
+	}
+	if index >= 232 {
+		level := uint32(8 + (index-232)*10)
+		return level<<16 | level<<8 | level
+	}
+	levels := []uint32{0, 95, 135, 175, 215, 255}
+	index -= 16
+	return levels[index/36]<<16 | levels[index/6%6]<<8 | levels[index%6]
+}
+
+func nearestANSI(value uint32) uint8 {
+	best, bestDistance := 0, -1
+	for i, ansi := range ansiColors {
+		distance := 0
+		for shift := 0; shift <= 16; shift += 8 {
+			delta := int(value>>shift&0xff) - int(ansi>>shift&0xff)
+			distance += delta * delta
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return uint8(best)
+}
+
+// style returns the theme style of a role
+func (app *App) style(role string) vaxis.Style {
+	return app.theme.style(role)
+}
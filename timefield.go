@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+)
+
+var nudgeSteps = []int{1, 5, 15} // Minutes
+
+// parseTimeInput accepts HH:MM:SS, HH:MM, H, or digit shorthand such as 930,
+// 1415 or 141530
+func parseTimeInput(input string) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	var parts []string
+	if strings.Contains(input, ":") {
+		parts = strings.Split(input, ":")
+	} else {
+		switch len(input) {
+		case 1, 2:
+			parts = []string{input}
+		case 3, 4:
+			parts = []string{input[:len(input)-2], input[len(input)-2:]}
+		case 5, 6:
+			parts = []string{input[:len(input)-4], input[len(input)-4 : len(input)-2], input[len(input)-2:]}
+		}
+	}
+	if len(parts) == 0 || len(parts) > 3 {
+		return time.Time{}, fmt.Errorf("invalid time %q", input)
+	}
+	limits := []int{23, 59, 59}
+	values := []int{0, 0, 0}
+	for i, part := range parts {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < 0 || value > limits[i] {
+			return time.Time{}, fmt.Errorf("invalid time %q", input)
+		}
+		values[i] = value
+	}
+	return time.Date(0, 1, 1, values[0], values[1], values[2], 0, time.UTC), nil
+}
+
+// parseDurationInput accepts H:MM[:SS], Go durations ("1h30m") or a bare number
+// of minutes
+func parseDurationInput(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	if strings.HasPrefix(input, "-") {
+		return 0, fmt.Errorf("negative duration %q", input)
+	}
+	if minutes, err := strconv.Atoi(input); err == nil {
+		return time.Duration(minutes) * time.Minute, nil
+	}
+	if strings.Contains(input, ":") {
+		parts := strings.Split(input, ":")
+		if len(parts) > 3 {
+			return 0, fmt.Errorf("invalid duration %q", input)
+		}
+		units := []time.Duration{time.Hour, time.Minute, time.Second}
+		var duration time.Duration
+		for i, part := range parts {
+			value, err := strconv.Atoi(part)
+			if err != nil || value < 0 {
+				return 0, fmt.Errorf("invalid duration %q", input)
+			}
+			duration += time.Duration(value) * units[i]
+		}
+		return duration, nil
+	}
+	return time.ParseDuration(input)
+}
+
+func formatClock(d time.Duration) string {
+	d = d.Round(time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+}
+
+func (app *App) roundTime(t time.Time) time.Time {
+	granularity := time.Duration(max(1, app.config.RoundMinutes)) * time.Minute
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight).Round(granularity))
+}
+
+func isTimeField(cursor int) bool {
+	return cursor == EntryCursorStart || cursor == EntryCursorEnd || cursor == EntryCursorDuration
+}
+
+func (app *App) timeFieldValue(cursor int) *string {
+	switch cursor {
+	case EntryCursorStart:
+		return &app.entryStartTime
+	case EntryCursorEnd:
+		return &app.entryEndTime
+	case EntryCursorDuration:
+		return &app.entryDuration
+	}
+	return nil
+}
+
+// entryDurationText shows the duration being typed, or the one implied by the
+// start and end times
+func (app *App) entryDurationText() string {
+	if app.entryEditCursor == EntryCursorDuration || app.entryDurationEdited {
+		return app.entryDuration
+	}
+	start, err1 := parseTimeInput(app.entryStartTime)
+	end, err2 := parseTimeInput(app.entryEndTime)
+	if err1 != nil || err2 != nil {
+		return ""
+	}
+	if end.Before(start) {
+		end = end.AddDate(0, 0, 1) // Overnight
+	}
+	return formatClock(end.Sub(start))
+}
+
+// commitTimeField normalizes the field being left, recomputing the end time
+// when the duration was edited
+func (app *App) commitTimeField() {
+	switch app.entryEditCursor {
+	case EntryCursorStart, EntryCursorEnd:
+		value := app.timeFieldValue(app.entryEditCursor)
+		if t, err := parseTimeInput(*value); err == nil {
+			*value = t.Format("15:04:05")
+		}
+	case EntryCursorDuration:
+		app.entryDurationEdited = false
+		duration, err := parseDurationInput(app.entryDuration)
+		start, err2 := parseTimeInput(app.entryStartTime)
+		if err == nil && err2 == nil {
+			app.entryEndTime = start.Add(duration).Format("15:04:05")
+		}
+	}
+}
+
+func (app *App) enterTimeField(cursor int) {
+	app.entryEditCursor = cursor
+	if cursor == EntryCursorDuration {
+		app.entryDuration = app.entryDurationText()
+		app.entryDurationEdited = true
+	}
+	if value := app.timeFieldValue(cursor); value != nil {
+		app.timeFieldCursor = len(*value)
+	}
+}
+
+func (app *App) nudgeTimeField(direction int) {
+	step := time.Duration(direction*nudgeSteps[app.nudgeStep]) * time.Minute
+	value := app.timeFieldValue(app.entryEditCursor)
+	if app.entryEditCursor == EntryCursorDuration {
+		duration, err := parseDurationInput(*value)
+		if err != nil {
+			return
+		}
+		duration += step
+		if duration < 0 {
+			duration = 0
+		}
+		*value = formatClock(duration)
+	} else {
+		t, err := parseTimeInput(*value)
+		if err != nil {
+			return
+		}
+		t = t.Add(step) // Not rounded, which would undo steps below round_minutes
+		if t.Day() != 1 {
+			return // Stay within the day
+		}
+		*value = t.Format("15:04:05")
+	}
+	app.timeFieldCursor = len(*value)
+}
+
+func (app *App) handleTimeFieldKeys(key vaxis.Key, isTimer bool) {
+	value := app.timeFieldValue(app.entryEditCursor)
+	if value == nil || isTimer {
+		return
+	}
+	app.timeFieldCursor = max(0, min(app.timeFieldCursor, len(*value)))
+	if app.pressed("timefield.left") {
+		app.timeFieldCursor = max(0, app.timeFieldCursor-1)
+	} else if app.pressed("timefield.right") {
+		app.timeFieldCursor = min(len(*value), app.timeFieldCursor+1)
+	} else if app.pressed("timefield.home") {
+		app.timeFieldCursor = 0
+	} else if app.pressed("timefield.end") {
+		app.timeFieldCursor = len(*value)
+	} else if app.pressed("timefield.backspace") {
+		if app.timeFieldCursor > 0 {
+			*value = (*value)[:app.timeFieldCursor-1] + (*value)[app.timeFieldCursor:]
+			app.timeFieldCursor--
+		}
+	} else if app.pressed("timefield.delete") {
+		if app.timeFieldCursor < len(*value) {
+			*value = (*value)[:app.timeFieldCursor] + (*value)[app.timeFieldCursor+1:]
+		}
+	} else if app.pressed("timefield.increase") {
+		app.nudgeTimeField(1)
+	} else if app.pressed("timefield.decrease") {
+		app.nudgeTimeField(-1)
+	} else if app.pressed("timefield.step") {
+		app.nudgeStep = (app.nudgeStep + 1) % len(nudgeSteps)
+	} else if app.pressed("timefield.now") && app.entryEditCursor != EntryCursorDuration {
+		now := app.roundTime(time.Now())
+		*value = now.Format("15:04:05")
+		app.timeFieldCursor = len(*value)
+	} else if app.pressed("timefield.round") && app.entryEditCursor != EntryCursorDuration {
+		if t, err := parseTimeInput(*value); err == nil {
+			*value = app.roundTime(t).Format("15:04:05")
+			app.timeFieldCursor = len(*value)
+		}
+	} else if len(key.Text) == 1 && (key.Text[0] >= '0' && key.Text[0] <= '9' || key.Text[0] == ':' || app.entryEditCursor == EntryCursorDuration && strings.ContainsAny(key.Text, "hms")) {
+		if len(*value) < 8 {
+			*value = (*value)[:app.timeFieldCursor] + key.Text + (*value)[app.timeFieldCursor:]
+			app.timeFieldCursor++
+		}
+	}
+}
+
+// timeFieldSegments renders a field value with the edit cursor shown as a
+// reversed cell
+func (app *App) timeFieldSegments(cursor int, text string, style vaxis.Style) []vaxis.Segment {
+	if app.entryEditCursor != cursor {
+		return []vaxis.Segment{{Text: text, Style: style}}
+	}
+	position := max(0, min(app.timeFieldCursor, len(text)))
+	cursorStyle := style
+	cursorStyle.Attribute |= vaxis.AttrReverse
+	style.UnderlineStyle = vaxis.UnderlineSingle
+	under := " "
+	if position < len(text) {
+		under = text[position : position+1]
+	}
+	after := ""
+	if position+1 < len(text) {
+		after = text[position+1:]
+	}
+	return []vaxis.Segment{
+		{Text: text[:position], Style: style},
+		{Text: under, Style: cursorStyle},
+		{Text: after, Style: style},
+		{Text: fmt.Sprintf("  ±%dm", nudgeSteps[app.nudgeStep]), Style: app.style("muted")},
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string // 15:04:05, empty for an error
+	}{
+		{"14:15", "14:15:00"},
+		{"14:15:30", "14:15:30"},
+		{" 9:05 ", "09:05:00"},
+		{"9", "09:00:00"},
+		{"14", "14:00:00"},
+		{"930", "09:30:00"},
+		{"1415", "14:15:00"},
+		{"141530", "14:15:30"},
+		{"0", "00:00:00"},
+		{"23:59:59", "23:59:59"},
+		{"24", ""},
+		{"24:00", ""},
+		{"12:60", ""},
+		{"12:30:60", ""},
+		{"960", ""},
+		{"1:2:3:4", ""},
+		{"1234567", ""},
+		{"", ""},
+		{"-1", ""},
+		{"12a", ""},
+		{"ab:cd", ""},
+	}
+	for _, test := range tests {
+		got, err := parseTimeInput(test.input)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("parseTimeInput(%q) = %s, want an error", test.input, got.Format("15:04:05"))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTimeInput(%q) failed: %v", test.input, err)
+		} else if got.Format("15:04:05") != test.want {
+			t.Errorf("parseTimeInput(%q) = %s, want %s", test.input, got.Format("15:04:05"), test.want)
+		}
+	}
+}
+
+func TestParseDurationInput(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+		err   bool
+	}{
+		{"90", 90 * time.Minute, false},
+		{" 15 ", 15 * time.Minute, false},
+		{"0", 0, false},
+		{"1:30", 90 * time.Minute, false},
+		{"1:30:15", time.Hour + 30*time.Minute + 15*time.Second, false},
+		{"0:05", 5 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"45m", 45 * time.Minute, false},
+		{"2h", 2 * time.Hour, false},
+		{"1:2:3:4", 0, true},
+		{"1:xx", 0, true},
+		{"-5", 0, true},
+		{"-1h", 0, true},
+		{"abc", 0, true},
+		{"", 0, true},
+	}
+	for _, test := range tests {
+		got, err := parseDurationInput(test.input)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseDurationInput(%q) = %s, want an error", test.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseDurationInput(%q) failed: %v", test.input, err)
+		} else if got != test.want {
+			t.Errorf("parseDurationInput(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
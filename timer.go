@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"git.sr.ht/~rockorager/vaxis"
@@ -33,15 +34,30 @@ func (app *App) fetchTimers() error {
 }
 
 func (app *App) startTimer() error {
+	return app.startTimerOnTask(0)
+}
+
+// startTimerOnTask starts a timer on the task, or without a task when it is 0
+func (app *App) startTimerOnTask(taskID int) error {
+	_, err := app.startTimerEntry(taskID)
+	return err
+}
+
+// startTimerEntry starts a timer on the task and returns the ID of the entry
+// it records, or 0 when a timer was already running
+func (app *App) startTimerEntry(taskID int) (int64, error) {
+	var entryID int64
 	if len(app.timers) == 0 {
 		type Body struct {
 			Action string `json:"action"`
+			TaskID int    `json:"task_id,omitempty"`
 		}
 		type Response struct {
 			EntryID int `json:"entry_id"`
 		}
 		body := Body{
 			Action: "start",
+			TaskID: taskID,
 		}
 		var reponse Response
 		resultChan := app.apiClient.CallAsyncWithChannel(CallOptions{
@@ -53,16 +69,54 @@ func (app *App) startTimer() error {
 		})
 		result := <-resultChan
 		if result.Error != nil {
-			return fmt.Errorf("failed API response: %w", result.Error)
+			return 0, fmt.Errorf("failed API response: %w", result.Error)
 		} else {
+			entryID = int64(reponse.EntryID)
 			app.fetchEntries(app.selectedDate)
 			app.fetchTimers()
 		}
 	}
-	return nil
+	return entryID, nil
+}
+
+// continueEntry starts a new timer with the task and description of the entry,
+// stopping the running timer first
+func (app *App) continueEntry(entry EntryResponse) {
+	if app.isEntryTimer(entry) {
+		app.statusMessage = "The timer is already running on this entry"
+		return
+	}
+	taskID, _ := strconv.Atoi(entry.TaskID)
+	description := entry.Description
+	go func() {
+		if err := app.stopTimers(); err != nil {
+			app.statusMessage = "Stop timer failed: " + err.Error()
+			app.vx.PostEvent(vaxis.Redraw{})
+			return
+		}
+		entryID, err := app.startTimerEntry(taskID)
+		if err == nil && entryID != 0 && description != "" {
+			err = app.sendEntryUpdate(EntryUpdate{ID: entryID, Description: &description})
+			app.fetchEntries(app.selectedDate)
+		}
+		if err != nil {
+			app.statusMessage = "Continue failed: " + err.Error()
+		} else if entry.Name != "" {
+			app.statusMessage = "Continuing " + entry.Name
+		}
+		app.vx.PostEvent(vaxis.Redraw{})
+	}()
 }
 
 func (app *App) stopTimers() error {
+	_, err := app.stopTimerEntry()
+	return err
+}
+
+// stopTimerEntry stops the running timer and returns the ID of the entry it
+// was recording, or 0 when no timer was running
+func (app *App) stopTimerEntry() (int64, error) {
+	var entryID int64
 	if len(app.timers) > 0 {
 		type Body struct {
 			Action string `json:"action"`
@@ -87,13 +141,23 @@ func (app *App) stopTimers() error {
 		})
 		result := <-resultChan
 		if result.Error != nil {
-			return fmt.Errorf("failed API response: %w", result.Error)
+			return 0, fmt.Errorf("failed API response: %w", result.Error)
 		}
+		entryID, _ = strconv.ParseInt(reponse.EntryID, 10, 64)
 		app.stopTimerTicker()
 		app.timers = []TimersRunningResponse{}
 		app.fetchEntries(app.selectedDate)
 	}
-	return nil
+	return entryID, nil
+}
+
+// runningTaskID returns the task of the running timer, or 0 without one
+func (app *App) runningTaskID() int {
+	if len(app.timers) == 0 || app.timers[0].TaskID == nil {
+		return 0
+	}
+	taskID, _ := strconv.Atoi(*app.timers[0].TaskID)
+	return taskID
 }
 
 func (app *App) stopTimerTicker() {
@@ -119,8 +183,9 @@ func (app *App) updateTimer() {
 			go func() {
 				for {
 					select {
-					case <-app.timerTicker.C:
+					case now := <-app.timerTicker.C:
 						app.elapsedTime = time.Since(startTime)
+						app.checkIdle(now)
 						app.vx.PostEvent(vaxis.Redraw{})
 					case <-app.timerDone:
 						return
@@ -130,6 +195,7 @@ func (app *App) updateTimer() {
 		}
 	} else {
 		app.elapsedTime = 0
+		app.idleSince = time.Time{}
 	}
 }
 
@@ -143,18 +209,25 @@ func (app *App) drawTimerWindow(win vaxis.Window) {
 	if app.timers == nil {
 		win.Println(2, vaxis.Segment{
 			Text:  "Loading timer status...",
-			Style: vaxis.Style{Attribute: vaxis.AttrItalic},
+			Style: app.style("loading"),
 		})
 		return
 	}
 
+	if segments := app.timerTargetSegments(); segments != nil {
+		win.Println(1, segments...)
+	}
+
+	gap := 1 // Blank rows between the lines when the panel has room for them
+	if _, height := win.Size(); height < timerSpacedRows {
+		gap = 0
+	}
+
 	buttonText := "Start timer ▶"
 	if len(app.timers) > 0 {
 		buttonText = "Stop timer ■"
 	}
-	focusedStyle := vaxis.Style{
-		Attribute: vaxis.AttrReverse,
-	}
+	focusedStyle := app.style("selection")
 	buttonStyle := vaxis.Style{
 		Attribute: vaxis.AttrBold,
 	}
@@ -167,15 +240,29 @@ func (app *App) drawTimerWindow(win vaxis.Window) {
 	win.Println(2,
 		vaxis.Segment{Text: buttonText, Style: currentStyle},
 	)
+	app.onMouse(win.New(0, 2, len([]rune(buttonText)), 1), func(col, row int) bool {
+		if !app.canChangeFocus() {
+			return false
+		}
+		app.focusedWindow = WinTimer
+		return app.runAction("timer.toggle")
+	}, nil)
 	if len(app.timers) > 0 {
 		startedAt, _ := time.ParseInLocation("2006-01-02 15:04:05", app.timers[0].StartedAt, app.currentMonth.Location())
-		win.Println(4, vaxis.Segment{Text: "Started: " + startedAt.Format("Monday, January 2, 2006 15:04:05")})
+		startedFormat := "Monday, January 2, 2006 15:04:05"
+		if width, _ := win.Size(); width < 45 {
+			startedFormat = "Jan 2 15:04:05" // Narrow column of the wide layout
+		}
+		win.Println(3+gap, vaxis.Segment{Text: "Started: " + startedAt.Format(startedFormat)})
 
 		hours := int(app.elapsedTime.Hours())
 		minutes := int(app.elapsedTime.Minutes()) % 60
 		seconds := int(app.elapsedTime.Seconds()) % 60
 		elapsedText := fmt.Sprintf("Elapsed: %02d:%02d:%02d", hours, minutes, seconds)
-		win.Println(6, vaxis.Segment{Text: elapsedText})
+		win.Println(4+2*gap, vaxis.Segment{Text: elapsedText})
+	}
+	if segments := app.focusSegments(); segments != nil {
+		win.Println(5+2*gap, segments...)
 	}
 }
 
@@ -183,16 +270,18 @@ func (app *App) handleTimerKeys(key vaxis.Key) bool {
 	if app.showQuitConfirm {
 		return false
 	}
-	if key.Matches('H') {
+	if app.pressed("timer.focus_calendar") {
 		app.focusedWindow = WinCalendar
-	} else if key.Matches('J') {
+	} else if app.pressed("timer.focus_entries") {
 		app.focusedWindow = WinEntries
-	} else if key.Matches(vaxis.KeyEnter) || key.Matches(vaxis.KeySpace) {
+	} else if app.pressed("timer.toggle") {
 		if len(app.timers) > 0 {
 			app.stopTimers()
 		} else {
 			app.startTimer()
 		}
+	} else if app.pressed("timer.focus") {
+		app.toggleFocus()
 	}
 	return false
 }
@@ -42,7 +42,7 @@ func (app *App) drawUserWindow(win vaxis.Window) {
 	if app.me.UserID == "" && app.me.Email == "" {
 		win.Println(0, vaxis.Segment{
 			Text:  "Loading user info...",
-			Style: vaxis.Style{Attribute: vaxis.AttrItalic},
+			Style: app.style("loading"),
 		})
 		return
 	}
@@ -59,5 +59,17 @@ func (app *App) drawUserWindow(win vaxis.Window) {
 		},
 		vaxis.Segment{
 			Text: email,
+		},
+		vaxis.Segment{
+			Text:  " " + app.statusMessage,
+			Style: app.style("error"),
 		})
+	if text, role := app.userTargetText(); text != "" {
+		width, _ := win.Size()
+		textWidth := len([]rune(text))
+		used := len([]rune(displayName+email+" "+app.statusMessage)) + 1
+		if used+textWidth <= width {
+			win.New(width-textWidth, 0, textWidth, 1).Println(0, vaxis.Segment{Text: text, Style: app.style(role)})
+		}
+	}
 }